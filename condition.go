@@ -121,32 +121,51 @@ func NotBetween(field string, start, end any) support.Condition {
 		Args: []any{start, end},
 	}
 }
-func Exists(condition any) support.Condition {
+
+// Exists 创建EXISTS条件,subquery 可以是 SQL 字符串、带参数的 Custom 条件或 Subquery
+func Exists(subquery any) support.Condition {
 	return support.Condition{
 		Type: support.EXISTS,
-		Left: condition,
+		Left: subquery,
 	}
 }
-func NotExists(condition any) support.Condition {
+
+// NotExists 创建NOT EXISTS条件,subquery 的形式与 Exists 相同
+func NotExists(subquery any) support.Condition {
 	return support.Condition{
 		Type: support.NOT_EXISTS,
-		Left: condition,
+		Left: subquery,
 	}
 }
-func InSubquery(field string, subquery string) support.Condition {
+
+// InSubquery 创建IN子查询条件,subquery 的形式与 Exists 相同
+func InSubquery(field string, subquery any) support.Condition {
 	return support.Condition{
 		Type:  support.IN_SUBQUERY,
 		Left:  field,
 		Right: subquery,
 	}
 }
-func NotInSubquery(field string, subquery string) support.Condition {
+
+// NotInSubquery 创建NOT IN子查询条件,subquery 的形式与 Exists 相同
+func NotInSubquery(field string, subquery any) support.Condition {
 	return support.Condition{
 		Type:  support.NOT_IN_SUBQUERY,
 		Left:  field,
 		Right: subquery,
 	}
 }
+
+// Subquery 创建由已注册模型 model 与查询条件构建的子查询,用于 Exists、InSubquery 等条件
+// column 为查询的字段,为空时查询常量 1;queryParts 与 FindAll 的参数相同,其中的参数按顺序合并到外层查询
+func Subquery(model any, column string, queryParts ...any) support.Subquery {
+	return support.Subquery{
+		Model:  model,
+		Column: column,
+		Parts:  queryParts,
+	}
+}
+
 func InValues(field string, values []any) support.Condition {
 	return support.Condition{
 		Type: support.IN_VALUES,
@@ -163,8 +182,8 @@ func NotInValues(field string, values []any) support.Condition {
 }
 func Limit(limit int) support.Condition {
 	return support.Condition{
-		Type:  support.LIMIT,
-		Right: limit,
+		Type: support.LIMIT,
+		Left: limit,
 	}
 }
 func LimitOffset(limit, offset int) support.Condition {
@@ -181,3 +200,88 @@ func Custom(condition string, args ...any) support.Condition {
 		Args: args,
 	}
 }
+
+// Named 创建使用命名参数的自定义条件
+// condition 中的 :name 与 @name(MySQL 中 @name 为用户变量,只支持 :name)从 arg 取值,arg 可以是键为字符串的 map 或已注册模型的结构体(指针),结构体按字段的 db 标签取值;
+// 构建查询时展开为占位符,缺少参数或 map 中存在未使用的键时查询返回错误
+func Named(condition string, arg any) support.Condition {
+	return support.Condition{
+		Type:  support.CUSTOM,
+		Left:  condition,
+		Right: support.Named{Arg: arg},
+	}
+}
+
+// OrderBy 创建ORDER BY子句,columns 为字段名(升序)或 Asc/Desc 条件
+func OrderBy(columns ...any) support.Condition {
+	return support.Condition{
+		Type: support.ORDER_BY,
+		Args: columns,
+	}
+}
+
+// Asc 创建按字段升序的ORDER BY子句
+func Asc(field string) support.Condition {
+	return support.Condition{
+		Type:  support.ORDER_BY,
+		Left:  field,
+		Right: "ASC",
+	}
+}
+
+// Desc 创建按字段降序的ORDER BY子句
+func Desc(field string) support.Condition {
+	return support.Condition{
+		Type:  support.ORDER_BY,
+		Left:  field,
+		Right: "DESC",
+	}
+}
+
+// GroupBy 创建GROUP BY子句
+func GroupBy(columns ...string) support.Condition {
+	args := make([]any, len(columns))
+	for i := 0; i < len(columns); i++ {
+		args[i] = columns[i]
+	}
+	return support.Condition{
+		Type: support.GROUP_BY,
+		Args: args,
+	}
+}
+
+// Having 创建HAVING子句,condition 按WHERE条件的规则解析,聚合表达式可使用 Custom
+func Having(condition support.Condition) support.Condition {
+	return support.Condition{
+		Type: support.HAVING,
+		Left: condition,
+	}
+}
+
+// After 创建游标分页条件,只返回排序位置在 cursor 之后的行
+// 需要与 ORDER BY 一起使用,排序字段组合必须唯一;cursor 为 CursorPaginate 返回的 NextCursor,为空时不生效
+func After(cursor string) support.Condition {
+	return support.Condition{
+		Type: support.AFTER,
+		Left: cursor,
+	}
+}
+
+// Set 创建 Update 的赋值,value 可以是普通值或 Expr 表达式
+// 与查询条件一起传给 Update、Save 或 UpdateReturning,传入 Set 时只更新这些字段
+func Set(column string, value any) support.Condition {
+	return support.Condition{
+		Type:  support.SET,
+		Left:  column,
+		Right: value,
+	}
+}
+
+// 聚合函数,用于 Aggregate 与 AggregateBy
+const (
+	AggCount = support.COUNT
+	AggSum   = support.SUM
+	AggAvg   = support.AVG
+	AggMin   = support.MIN
+	AggMax   = support.MAX
+)
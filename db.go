@@ -17,7 +17,7 @@ package opao
 import (
 	"database/sql"
 	"errors"
-	"reflect"
+	"time"
 
 	"github.com/OblivionOcean/opao/support"
 	"github.com/OblivionOcean/opao/support/mysql"
@@ -43,20 +43,22 @@ func NewDatabase(sqlDriverName, linkInfo string) (*Database, error) {
 	db := &Database{Conn: conn}
 	db.sqlDriverName = sqlDriverName
 	db.ORM = support.ORM{}
-	var driver func(*sql.DB, any, reflect.Type, string, []support.Elem, error) support.ObjectORM
+	var driver support.Driver
+	var dialect support.Dialect
 
 	switch db.sqlDriverName {
 	case "mysql":
-		driver = mysql.NewMySQL
+		driver, dialect = mysql.NewMySQL, support.DialectMySQL
 	case "postgres", "pg", "pgsql":
-		driver = pg.NewPg
+		driver, dialect = pg.NewPg, support.DialectPostgres
 	case "sqlite3", "sqlite":
-		driver = sqlite.NewSqlite
+		driver, dialect = sqlite.NewSqlite, support.DialectSQLite
 	default:
 		return nil, errors.New("driver not supported")
 	}
 
 	db.ORM.Init(db.Conn, driver)
+	db.Config().Dialect = dialect
 
 	return db, nil
 }
@@ -76,3 +78,15 @@ func (db *Database) Close() error {
 func (db *Database) GetConn() *sql.DB {
 	return db.Conn
 }
+
+// SetTimeout 设置默认查询超时
+// 当调用方传入的 context 没有截止时间(如 context.Background())时生效,d <= 0 表示不限制
+func (db *Database) SetTimeout(d time.Duration) {
+	db.Config().Timeout = d
+}
+
+// SetAllowGlobal 设置是否允许没有条件的 Update/Save/Delete 写入全表
+// 默认不允许,此时这些操作返回 ErrMissingWhere;也可以通过 ObjectORM.AllowGlobal 单次允许
+func (db *Database) SetAllowGlobal(allow bool) {
+	db.Config().AllowGlobal = allow
+}
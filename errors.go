@@ -0,0 +1,45 @@
+// Copyright 2024 OblivionOcean
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package opao
+
+import "github.com/OblivionOcean/opao/support"
+
+var (
+	// ErrNoPrimaryKey 模型未定义主键
+	ErrNoPrimaryKey = support.ErrNoPrimaryKey
+	// ErrMissingWhere Update/Save/Delete 没有条件且未允许全表写入
+	ErrMissingWhere = support.ErrMissingWhere
+	// ErrWriteClause Update/Save/Delete 的条件中包含 GROUP BY、HAVING、ORDER BY 或 LIMIT
+	ErrWriteClause = support.ErrWriteClause
+	// ErrLockOutsideTx ForUpdate/ForShare 等行锁在 Database.Transaction 开启的事务之外使用
+	ErrLockOutsideTx = support.ErrLockOutsideTx
+	// ErrRecordNotFound Find/FindByPK 等查询单条记录时没有结果,errors.Is 同时与 sql.ErrNoRows 匹配
+	ErrRecordNotFound = support.ErrRecordNotFound
+
+	// ErrDuplicateKey 违反唯一约束或主键约束
+	ErrDuplicateKey = support.ErrDuplicateKey
+	// ErrForeignKeyViolation 违反外键约束
+	ErrForeignKeyViolation = support.ErrForeignKeyViolation
+	// ErrNotNullViolation 向非空字段写入 NULL
+	ErrNotNullViolation = support.ErrNotNullViolation
+	// ErrDeadlock 事务因死锁被数据库中止
+	ErrDeadlock = support.ErrDeadlock
+	// ErrSerialization 可串行化事务冲突,可以重试整个事务
+	ErrSerialization = support.ErrSerialization
+)
+
+// DBError 由数据库驱动错误转换得到的错误,Code 为驱动返回的错误码
+// errors.Is 与 ErrDuplicateKey 等哨兵错误匹配,errors.As 可继续取得驱动原始的错误类型
+type DBError = support.DBError
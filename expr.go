@@ -0,0 +1,44 @@
+// Copyright 2024 OblivionOcean
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package opao
+
+import "github.com/OblivionOcean/opao/support"
+
+// Expr 创建 SQL 表达式,用于 Set 的值或 SelectExpr 的字段列表
+// sql 使用 ? 占位符,args 中的 Expr 展开到对应的占位符处
+func Expr(sql string, args ...any) support.Expr {
+	return support.Expr{SQL: sql, Args: args}
+}
+
+// Column 创建引用字段的表达式,可以作为其他表达式的参数
+func Column(name string) support.Expr {
+	return support.Column(name)
+}
+
+// Coalesce 创建 COALESCE 表达式,column 为字符串时是字段名,values 中的 Expr 渲染为表达式,其他值以占位符绑定
+func Coalesce(column any, values ...any) support.Expr {
+	return support.Coalesce(column, values...)
+}
+
+// Now 创建当前时间表达式,按方言渲染为 NOW() 或 CURRENT_TIMESTAMP
+func Now() support.Expr {
+	return support.Now()
+}
+
+// DateTrunc 创建将时间截断到 unit(year、month、day、hour、minute)的表达式
+// value 为字符串时是字段名;PostgreSQL 使用 DATE_TRUNC,MySQL 与 SQLite 按格式化后的时间模拟
+func DateTrunc(unit string, value any) support.Expr {
+	return support.DateTrunc(unit, value)
+}
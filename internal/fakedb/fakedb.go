@@ -0,0 +1,175 @@
+// Copyright 2024 OblivionOcean
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package fakedb 提供记录 SQL 的 database/sql 驱动,用于测试生成的 SQL 与参数
+package fakedb
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"sync"
+)
+
+// Call 一次执行的 SQL 与参数
+type Call struct {
+	SQL  string
+	Args []any
+}
+
+// Rows 查询返回的结果集
+type Rows struct {
+	Columns []string
+	Values  [][]any
+}
+
+// Driver 记录所有执行的 SQL,查询结果与执行错误由 Query、Exec 决定
+type Driver struct {
+	mu    sync.Mutex
+	calls []Call
+
+	// Query 返回查询的结果集,为 nil 时返回没有列与行的结果集
+	Query func(query string, args []any) (Rows, error)
+	// Exec 返回执行的错误与受影响的行数,为 nil 时每次执行影响 1 行
+	Exec func(query string, args []any) (driver.Result, error)
+}
+
+// Open 创建使用 d 的 *sql.DB,只使用一个连接,保证事务与保存点在同一连接上执行
+func Open(d *Driver) *sql.DB {
+	db := sql.OpenDB(connector{d})
+	db.SetMaxOpenConns(1)
+	return db
+}
+
+// Calls 返回已执行的 SQL
+func (d *Driver) Calls() []Call {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]Call(nil), d.calls...)
+}
+
+// Last 返回最后执行的 SQL,没有时返回空的 Call
+func (d *Driver) Last() Call {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if len(d.calls) == 0 {
+		return Call{}
+	}
+	return d.calls[len(d.calls)-1]
+}
+
+// Reset 清空已记录的 SQL
+func (d *Driver) Reset() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.calls = nil
+}
+
+func (d *Driver) record(query string, named []driver.NamedValue) []any {
+	args := make([]any, len(named))
+	for i := 0; i < len(named); i++ {
+		args[i] = named[i].Value
+	}
+	d.mu.Lock()
+	d.calls = append(d.calls, Call{SQL: query, Args: args})
+	d.mu.Unlock()
+	return args
+}
+
+type connector struct{ d *Driver }
+
+func (c connector) Connect(context.Context) (driver.Conn, error) { return &conn{c.d}, nil }
+func (c connector) Driver() driver.Driver                        { return drv{c.d} }
+
+type drv struct{ d *Driver }
+
+func (d drv) Open(string) (driver.Conn, error) { return &conn{d.d}, nil }
+
+type conn struct{ d *Driver }
+
+func (c *conn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("fakedb: prepared statements are not supported")
+}
+func (c *conn) Close() error { return nil }
+func (c *conn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	c.d.record("BEGIN", nil)
+	return tx{c.d}, nil
+}
+
+// CheckNamedValue 接受任意参数,原样记录
+func (c *conn) CheckNamedValue(*driver.NamedValue) error { return nil }
+
+func (c *conn) ExecContext(ctx context.Context, query string, named []driver.NamedValue) (driver.Result, error) {
+	args := c.d.record(query, named)
+	if c.d.Exec != nil {
+		return c.d.Exec(query, args)
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (c *conn) QueryContext(ctx context.Context, query string, named []driver.NamedValue) (driver.Rows, error) {
+	args := c.d.record(query, named)
+	var r Rows
+	if c.d.Query != nil {
+		var err error
+		if r, err = c.d.Query(query, args); err != nil {
+			return nil, err
+		}
+	}
+	return &rows{r: r}, nil
+}
+
+type tx struct{ d *Driver }
+
+func (t tx) Commit() error   { t.d.record("COMMIT", nil); return nil }
+func (t tx) Rollback() error { t.d.record("ROLLBACK", nil); return nil }
+
+type rows struct {
+	r Rows
+	i int
+}
+
+func (r *rows) Columns() []string { return r.r.Columns }
+func (r *rows) Close() error      { return nil }
+
+func (r *rows) Next(dest []driver.Value) error {
+	if r.i >= len(r.r.Values) {
+		return io.EOF
+	}
+	for i := 0; i < len(dest) && i < len(r.r.Values[r.i]); i++ {
+		dest[i] = r.r.Values[r.i][i]
+	}
+	r.i++
+	return nil
+}
+
+// Result 返回固定的 LastInsertId 与 RowsAffected
+type Result struct {
+	ID       int64
+	Affected int64
+}
+
+func (r Result) LastInsertId() (int64, error) { return r.ID, nil }
+func (r Result) RowsAffected() (int64, error) { return r.Affected, nil }
+
+// Value 返回单行单列的结果集,用于 COUNT、VERSION() 等查询
+func Value(v any) Rows {
+	return Rows{Columns: []string{"v"}, Values: [][]any{{v}}}
+}
@@ -0,0 +1,48 @@
+// Copyright 2024 OblivionOcean
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.23
+// +build go1.23
+
+package opao
+
+import (
+	"context"
+	"errors"
+	"iter"
+)
+
+// errStopIter 用于在 range 循环 break 时中止 Each
+var errStopIter = errors.New("opao: iteration stopped")
+
+// Iter 返回逐行扫描查询结果的迭代器
+// 查询在开始 range 时执行,出错时产出一次 (nil, err);循环 break 后关闭 rows
+func (m *TypedORM[T]) Iter(queryParts ...any) iter.Seq2[*T, error] {
+	return m.IterContext(context.Background(), queryParts...)
+}
+
+// IterContext 与 Iter 相同,使用 ctx 控制超时与取消
+func (m *TypedORM[T]) IterContext(ctx context.Context, queryParts ...any) iter.Seq2[*T, error] {
+	return func(yield func(*T, error) bool) {
+		err := m.EachContext(ctx, func(obj *T) error {
+			if !yield(obj, nil) {
+				return errStopIter
+			}
+			return nil
+		}, queryParts...)
+		if err != nil && err != errStopIter {
+			yield(nil, err)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+// Copyright 2024 OblivionOcean
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.23
+// +build go1.23
+
+package opao_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/OblivionOcean/opao"
+	"github.com/OblivionOcean/opao/internal/fakedb"
+)
+
+func TestIter(t *testing.T) {
+	db, d := newDB(t)
+	d.Query = func(string, []any) (fakedb.Rows, error) {
+		return fakedb.Rows{Columns: []string{"id", "name"}, Values: [][]any{{int64(1), "a"}, {int64(2), "b"}, {int64(3), "c"}}}, nil
+	}
+	var ids []int64
+	for u, err := range opao.Model[UserProfile](db).Iter() {
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, u.Id)
+		if len(ids) == 2 {
+			break
+		}
+	}
+	if len(ids) != 2 || ids[0] != 1 || ids[1] != 2 {
+		t.Fatalf("got %v, want [1 2]", ids)
+	}
+}
+
+func TestIterError(t *testing.T) {
+	db, d := newDB(t)
+	errQuery := errors.New("query failed")
+	d.Query = func(string, []any) (fakedb.Rows, error) { return fakedb.Rows{}, errQuery }
+	n := 0
+	for u, err := range opao.Model[UserProfile](db).Iter() {
+		n++
+		if u != nil || !errors.Is(err, errQuery) {
+			t.Fatalf("got (%v, %v), want (nil, errQuery)", u, err)
+		}
+	}
+	if n != 1 {
+		t.Fatalf("yielded %d times, want 1", n)
+	}
+}
@@ -0,0 +1,747 @@
+// Copyright 2024 OblivionOcean
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package opao
+
+import (
+	"context"
+	"errors"
+	"reflect"
+
+	"github.com/OblivionOcean/opao/support"
+	"github.com/OblivionOcean/opao/utils"
+)
+
+// Session Database 与 Tx 共有的模型注册与加载接口
+type Session interface {
+	Register(tableName string, object any) error
+	Load(object any) support.ObjectORM
+	Registered(object any) bool
+	Raw(sqlStr string, args ...any) *support.RawQuery
+	RawNamed(query string, arg any) *support.RawQuery
+}
+
+// Tabler 自定义表名,未实现时使用类型名的蛇形命名作为表名
+type Tabler interface {
+	TableName() string
+}
+
+// TypedORM 绑定到模型类型 T 的类型安全 ORM
+// 查询结果直接返回 *T / []T,无需类型断言
+type TypedORM[T any] struct {
+	sess     Session
+	err      error
+	reuse    bool                   // Each/Iter 遍历时复用行对象
+	selects  []string               // Select 指定的字段
+	omits    []string               // Omit 排除的字段
+	exprs    []support.Expr         // SelectExpr 指定的字段表达式
+	distinct []string               // Distinct 去重的字段,非 nil 时查询使用 SELECT DISTINCT
+	lock     support.Lock           // ForUpdate/ForShare 等设置的行锁
+	joins    []modelJoin            // Join/LeftJoin/InnerJoin 连接的模型
+	ctes     []modelCTE             // With/WithRecursive 定义的公用表表达式
+	from     string                 // From 指定的读取来源
+	sets     []support.SetOperation // Union/Intersect 等追加的 SELECT
+}
+
+// modelJoin TypedORM 记录的一次 JOIN,在加载时应用到 ObjectORM
+type modelJoin struct {
+	kind  string
+	model any
+	on    []string
+}
+
+// modelCTE TypedORM 记录的一项公用表表达式,在加载时应用到 ObjectORM
+type modelCTE struct {
+	name      string
+	subquery  any
+	recursive bool
+}
+
+// Model 创建模型类型 T 的类型安全 ORM
+// T 必须是结构体类型;未注册时自动注册,表名取自 TableName 方法或类型名的蛇形命名
+// Go 泛型无法约束 T 为结构体,非结构体类型会在调用时返回错误
+func Model[T any](sess Session) *TypedORM[T] {
+	m := &TypedORM[T]{sess: sess}
+	objType := reflect.TypeOf((*T)(nil)).Elem()
+	if objType.Kind() != reflect.Struct {
+		m.err = errors.New("model type must be a struct")
+		return m
+	}
+	if sess.Registered((*T)(nil)) {
+		return m
+	}
+	var table string
+	// 使用零值对象调用,TableName 可以定义在值接收者上
+	if tabler, ok := any(new(T)).(Tabler); ok {
+		table = tabler.TableName()
+	} else {
+		table = utils.SnakeCase(objType.Name())
+	}
+	m.err = sess.Register(table, (*T)(nil))
+	return m
+}
+
+// Error 返回模型初始化错误
+func (m *TypedORM[T]) Error() error {
+	return m.err
+}
+
+// Load 加载 obj 并返回其 ObjectORM,已应用 Select/Omit
+func (m *TypedORM[T]) Load(obj *T) support.ObjectORM {
+	return m.load(obj)
+}
+
+// Reuse 返回复用行对象的副本
+// Each/Iter 遍历时每行都扫描到同一个 *T 中,回调中需要保留的数据应自行复制
+func (m *TypedORM[T]) Reuse() *TypedORM[T] {
+	cp := *m
+	cp.reuse = true
+	return &cp
+}
+
+// Select 返回仅读写 columns 字段的副本,参见 support.ObjectORM.Select
+func (m *TypedORM[T]) Select(columns ...string) *TypedORM[T] {
+	cp := *m
+	cp.selects = columns
+	return &cp
+}
+
+// SelectExpr 返回以表达式代替字段查询的副本,参见 support.ObjectORM.SelectExpr
+func (m *TypedORM[T]) SelectExpr(exprs ...support.Expr) *TypedORM[T] {
+	cp := *m
+	cp.exprs = append(append([]support.Expr(nil), m.exprs...), exprs...)
+	return &cp
+}
+
+// Omit 返回排除 columns 字段的副本,参见 support.ObjectORM.Omit
+func (m *TypedORM[T]) Omit(columns ...string) *TypedORM[T] {
+	cp := *m
+	cp.omits = append(append([]string(nil), m.omits...), columns...)
+	return &cp
+}
+
+// Distinct 返回查询时去除重复行的副本,参见 support.ObjectORM.Distinct
+func (m *TypedORM[T]) Distinct(columns ...string) *TypedORM[T] {
+	cp := *m
+	cp.distinct = append([]string{}, columns...)
+	return &cp
+}
+
+// ForUpdate 返回查询时加排他行锁的副本,参见 support.ObjectORM.ForUpdate
+func (m *TypedORM[T]) ForUpdate() *TypedORM[T] {
+	cp := *m
+	cp.lock.Mode = support.LockUpdate
+	return &cp
+}
+
+// ForShare 返回查询时加共享行锁的副本,参见 support.ObjectORM.ForShare
+func (m *TypedORM[T]) ForShare() *TypedORM[T] {
+	cp := *m
+	cp.lock.Mode = support.LockShare
+	return &cp
+}
+
+// NoWait 返回行已被锁定时立即报错的副本,需要与 ForUpdate 或 ForShare 一起使用
+func (m *TypedORM[T]) NoWait() *TypedORM[T] {
+	cp := *m
+	cp.lock.Wait = support.LockNoWait
+	return &cp
+}
+
+// SkipLocked 返回跳过已被锁定行的副本,需要与 ForUpdate 或 ForShare 一起使用
+func (m *TypedORM[T]) SkipLocked() *TypedORM[T] {
+	cp := *m
+	cp.lock.Wait = support.LockSkipLocked
+	return &cp
+}
+
+// Join 返回与 model 进行 JOIN 的副本,参见 support.ObjectORM.Join
+func (m *TypedORM[T]) Join(model any, on ...string) *TypedORM[T] {
+	return m.join(support.JoinDefault, model, on)
+}
+
+// LeftJoin 返回与 model 进行 LEFT JOIN 的副本,参见 support.ObjectORM.Join
+func (m *TypedORM[T]) LeftJoin(model any, on ...string) *TypedORM[T] {
+	return m.join(support.JoinLeft, model, on)
+}
+
+// InnerJoin 返回与 model 进行 INNER JOIN 的副本,参见 support.ObjectORM.Join
+func (m *TypedORM[T]) InnerJoin(model any, on ...string) *TypedORM[T] {
+	return m.join(support.JoinInner, model, on)
+}
+
+// join 返回追加了一次 JOIN 的副本
+func (m *TypedORM[T]) join(kind string, model any, on []string) *TypedORM[T] {
+	cp := *m
+	cp.joins = append(append([]modelJoin(nil), m.joins...), modelJoin{kind: kind, model: model, on: on})
+	return &cp
+}
+
+// With 返回在语句前添加公用表表达式的副本,参见 support.ObjectORM.With
+func (m *TypedORM[T]) With(name string, subquery any) *TypedORM[T] {
+	return m.with(name, subquery, false)
+}
+
+// WithRecursive 返回添加递归公用表表达式的副本,参见 support.ObjectORM.WithRecursive
+func (m *TypedORM[T]) WithRecursive(name string, subquery any) *TypedORM[T] {
+	return m.with(name, subquery, true)
+}
+
+// with 返回追加了一项公用表表达式的副本
+func (m *TypedORM[T]) with(name string, subquery any, recursive bool) *TypedORM[T] {
+	cp := *m
+	cp.ctes = append(append([]modelCTE(nil), m.ctes...), modelCTE{name: name, subquery: subquery, recursive: recursive})
+	return &cp
+}
+
+// From 返回从 name 读取数据的副本,参见 support.ObjectORM.From
+func (m *TypedORM[T]) From(name string) *TypedORM[T] {
+	cp := *m
+	cp.from = name
+	return &cp
+}
+
+// Union 返回与 queryParts 匹配的记录合并去重的副本,参见 support.ObjectORM.Union
+func (m *TypedORM[T]) Union(queryParts ...any) *TypedORM[T] {
+	return m.setOperation(support.SetUnion, queryParts)
+}
+
+// UnionAll 与 Union 相同,但保留重复的记录
+func (m *TypedORM[T]) UnionAll(queryParts ...any) *TypedORM[T] {
+	return m.setOperation(support.SetUnionAll, queryParts)
+}
+
+// Intersect 返回与 queryParts 匹配的记录取交集的副本,参见 support.ObjectORM.Intersect
+func (m *TypedORM[T]) Intersect(queryParts ...any) *TypedORM[T] {
+	return m.setOperation(support.SetIntersect, queryParts)
+}
+
+// Except 返回排除与 queryParts 匹配的记录的副本,参见 support.ObjectORM.Except
+func (m *TypedORM[T]) Except(queryParts ...any) *TypedORM[T] {
+	return m.setOperation(support.SetExcept, queryParts)
+}
+
+// setOperation 返回追加了一个集合运算的副本
+func (m *TypedORM[T]) setOperation(op string, queryParts []any) *TypedORM[T] {
+	cp := *m
+	cp.sets = append(append([]support.SetOperation(nil), m.sets...), support.SetOperation{Op: op, Parts: queryParts})
+	return &cp
+}
+
+// load 加载 obj 并应用 Select/Omit/SelectExpr/Distinct、行锁、公用表表达式、JOIN 与集合运算
+func (m *TypedORM[T]) load(obj *T) support.ObjectORM {
+	orm := m.sess.Load(obj)
+	if m.selects != nil {
+		orm = orm.Select(m.selects...)
+	}
+	if m.omits != nil {
+		orm = orm.Omit(m.omits...)
+	}
+	if m.exprs != nil {
+		orm = orm.SelectExpr(m.exprs...)
+	}
+	if m.distinct != nil {
+		orm = orm.Distinct(m.distinct...)
+	}
+	switch m.lock.Mode {
+	case support.LockUpdate:
+		orm = orm.ForUpdate()
+	case support.LockShare:
+		orm = orm.ForShare()
+	}
+	switch m.lock.Wait {
+	case support.LockNoWait:
+		orm = orm.NoWait()
+	case support.LockSkipLocked:
+		orm = orm.SkipLocked()
+	}
+	for i := 0; i < len(m.ctes); i++ {
+		if m.ctes[i].recursive {
+			orm = orm.WithRecursive(m.ctes[i].name, m.ctes[i].subquery)
+		} else {
+			orm = orm.With(m.ctes[i].name, m.ctes[i].subquery)
+		}
+	}
+	if m.from != "" {
+		orm = orm.From(m.from)
+	}
+	for i := 0; i < len(m.joins); i++ {
+		switch m.joins[i].kind {
+		case support.JoinLeft:
+			orm = orm.LeftJoin(m.joins[i].model, m.joins[i].on...)
+		case support.JoinInner:
+			orm = orm.InnerJoin(m.joins[i].model, m.joins[i].on...)
+		default:
+			orm = orm.Join(m.joins[i].model, m.joins[i].on...)
+		}
+	}
+	for i := 0; i < len(m.sets); i++ {
+		switch m.sets[i].Op {
+		case support.SetUnionAll:
+			orm = orm.UnionAll(m.sets[i].Parts...)
+		case support.SetIntersect:
+			orm = orm.Intersect(m.sets[i].Parts...)
+		case support.SetExcept:
+			orm = orm.Except(m.sets[i].Parts...)
+		default:
+			orm = orm.Union(m.sets[i].Parts...)
+		}
+	}
+	return orm
+}
+
+// Each 逐行遍历查询结果,fn 返回错误时停止遍历并返回该错误
+// 默认超时只限制查询执行到返回结果集,不限制之后的遍历
+func (m *TypedORM[T]) Each(fn func(obj *T) error, queryParts ...any) error {
+	return m.EachContext(context.Background(), fn, queryParts...)
+}
+
+// EachContext 与 Each 相同,使用 ctx 控制超时与取消
+func (m *TypedORM[T]) EachContext(ctx context.Context, fn func(obj *T) error, queryParts ...any) error {
+	if m.err != nil {
+		return m.err
+	}
+	orm := m.load(new(T))
+	if m.reuse {
+		orm = orm.Reuse()
+	}
+	return orm.EachContext(ctx, func(obj any) error {
+		return fn(obj.(*T))
+	}, queryParts...)
+}
+
+// Find 查询单条记录,未找到时返回 ErrRecordNotFound
+func (m *TypedORM[T]) Find(queryParts ...any) (*T, error) {
+	return m.FindContext(context.Background(), queryParts...)
+}
+
+// FindContext 与 Find 相同,使用 ctx 控制超时与取消
+func (m *TypedORM[T]) FindContext(ctx context.Context, queryParts ...any) (*T, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	obj := new(T)
+	if _, err := m.load(obj).FindContext(ctx, queryParts...); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+// FindByPK 按主键查询单条记录,未找到时返回 ErrRecordNotFound
+func (m *TypedORM[T]) FindByPK(ids ...any) (*T, error) {
+	return m.FindByPKContext(context.Background(), ids...)
+}
+
+// FindByPKContext 与 FindByPK 相同,使用 ctx 控制超时与取消
+func (m *TypedORM[T]) FindByPKContext(ctx context.Context, ids ...any) (*T, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	obj := new(T)
+	if _, err := m.load(obj).FindByPKContext(ctx, ids...); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+// FindAll 查询多条记录
+func (m *TypedORM[T]) FindAll(queryParts ...any) ([]T, error) {
+	return m.FindAllContext(context.Background(), queryParts...)
+}
+
+// FindAllContext 与 FindAll 相同,使用 ctx 控制超时与取消
+func (m *TypedORM[T]) FindAllContext(ctx context.Context, queryParts ...any) ([]T, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return toSlice[T](m.load(new(T)).FindAllContext(ctx, queryParts...))
+}
+
+// Scan 查询并将结果扫描到组合结构体 dest,参见 support.ObjectORM.Scan
+func (m *TypedORM[T]) Scan(dest any, queryParts ...any) error {
+	return m.ScanContext(context.Background(), dest, queryParts...)
+}
+
+// ScanContext 与 Scan 相同,使用 ctx 控制超时与取消
+func (m *TypedORM[T]) ScanContext(ctx context.Context, dest any, queryParts ...any) error {
+	if m.err != nil {
+		return m.err
+	}
+	return m.load(new(T)).ScanContext(ctx, dest, queryParts...)
+}
+
+// Pluck 查询单个字段,将每行的值依次写入 dest 指向的切片(覆盖原有内容)
+func (m *TypedORM[T]) Pluck(column string, dest any, queryParts ...any) error {
+	return m.PluckContext(context.Background(), column, dest, queryParts...)
+}
+
+// PluckContext 与 Pluck 相同,使用 ctx 控制超时与取消
+func (m *TypedORM[T]) PluckContext(ctx context.Context, column string, dest any, queryParts ...any) error {
+	if m.err != nil {
+		return m.err
+	}
+	return m.load(new(T)).PluckContext(ctx, column, dest, queryParts...)
+}
+
+// Page 分页查询的结果
+type Page[T any] struct {
+	Items []T // 当前页的行对象
+	Total int // 满足条件的总行数
+	Page  int // 当前页码,从 1 开始
+	Size  int // 每页行数
+	Pages int // 总页数
+}
+
+// CursorPage 游标分页查询的结果
+type CursorPage[T any] struct {
+	Items      []T    // 当前页的行对象
+	NextCursor string // 下一页的游标,没有下一页时为空
+	HasNext    bool   // 是否还有下一页
+}
+
+// Paginate 分页查询,返回第 page 页的行对象、总行数与分页信息
+func (m *TypedORM[T]) Paginate(page, size int, queryParts ...any) (Page[T], error) {
+	return m.PaginateContext(context.Background(), page, size, queryParts...)
+}
+
+// PaginateContext 与 Paginate 相同,使用 ctx 控制超时与取消
+func (m *TypedORM[T]) PaginateContext(ctx context.Context, page, size int, queryParts ...any) (Page[T], error) {
+	if m.err != nil {
+		return Page[T]{}, m.err
+	}
+	result, err := m.load(new(T)).PaginateContext(ctx, page, size, queryParts...)
+	if err != nil {
+		return Page[T]{}, err
+	}
+	items, _ := toSlice[T](result.Items, nil)
+	return Page[T]{Items: items, Total: result.Total, Page: result.Page, Size: result.Size, Pages: result.Pages}, nil
+}
+
+// CursorPaginate 游标分页查询,返回 cursor 之后的至多 size 行,queryParts 中必须包含 ORDER BY
+func (m *TypedORM[T]) CursorPaginate(cursor string, size int, queryParts ...any) (CursorPage[T], error) {
+	return m.CursorPaginateContext(context.Background(), cursor, size, queryParts...)
+}
+
+// CursorPaginateContext 与 CursorPaginate 相同,使用 ctx 控制超时与取消
+func (m *TypedORM[T]) CursorPaginateContext(ctx context.Context, cursor string, size int, queryParts ...any) (CursorPage[T], error) {
+	if m.err != nil {
+		return CursorPage[T]{}, m.err
+	}
+	result, err := m.load(new(T)).CursorPaginateContext(ctx, cursor, size, queryParts...)
+	if err != nil {
+		return CursorPage[T]{}, err
+	}
+	items, _ := toSlice[T](result.Items, nil)
+	return CursorPage[T]{Items: items, NextCursor: result.NextCursor, HasNext: result.HasNext}, nil
+}
+
+// Count 统计记录数量
+func (m *TypedORM[T]) Count(queryParts ...any) (int, error) {
+	return m.CountContext(context.Background(), queryParts...)
+}
+
+// CountContext 与 Count 相同,使用 ctx 控制超时与取消
+func (m *TypedORM[T]) CountContext(ctx context.Context, queryParts ...any) (int, error) {
+	if m.err != nil {
+		return 0, m.err
+	}
+	return m.load(new(T)).CountContext(ctx, queryParts...)
+}
+
+// Sum 计算 column 的总和并扫描到 dest
+func (m *TypedORM[T]) Sum(dest any, column string, queryParts ...any) error {
+	return m.SumContext(context.Background(), dest, column, queryParts...)
+}
+
+// SumContext 与 Sum 相同,使用 ctx 控制超时与取消
+func (m *TypedORM[T]) SumContext(ctx context.Context, dest any, column string, queryParts ...any) error {
+	if m.err != nil {
+		return m.err
+	}
+	return m.load(new(T)).SumContext(ctx, dest, column, queryParts...)
+}
+
+// Avg 计算 column 的平均值并扫描到 dest
+func (m *TypedORM[T]) Avg(dest any, column string, queryParts ...any) error {
+	return m.AvgContext(context.Background(), dest, column, queryParts...)
+}
+
+// AvgContext 与 Avg 相同,使用 ctx 控制超时与取消
+func (m *TypedORM[T]) AvgContext(ctx context.Context, dest any, column string, queryParts ...any) error {
+	if m.err != nil {
+		return m.err
+	}
+	return m.load(new(T)).AvgContext(ctx, dest, column, queryParts...)
+}
+
+// Min 计算 column 的最小值并扫描到 dest
+func (m *TypedORM[T]) Min(dest any, column string, queryParts ...any) error {
+	return m.MinContext(context.Background(), dest, column, queryParts...)
+}
+
+// MinContext 与 Min 相同,使用 ctx 控制超时与取消
+func (m *TypedORM[T]) MinContext(ctx context.Context, dest any, column string, queryParts ...any) error {
+	if m.err != nil {
+		return m.err
+	}
+	return m.load(new(T)).MinContext(ctx, dest, column, queryParts...)
+}
+
+// Max 计算 column 的最大值并扫描到 dest
+func (m *TypedORM[T]) Max(dest any, column string, queryParts ...any) error {
+	return m.MaxContext(context.Background(), dest, column, queryParts...)
+}
+
+// MaxContext 与 Max 相同,使用 ctx 控制超时与取消
+func (m *TypedORM[T]) MaxContext(ctx context.Context, dest any, column string, queryParts ...any) error {
+	if m.err != nil {
+		return m.err
+	}
+	return m.load(new(T)).MaxContext(ctx, dest, column, queryParts...)
+}
+
+// CountBy 按 groupColumn 分组统计记录数量
+func (m *TypedORM[T]) CountBy(groupColumn string, queryParts ...any) (map[any]int64, error) {
+	return m.CountByContext(context.Background(), groupColumn, queryParts...)
+}
+
+// CountByContext 与 CountBy 相同,使用 ctx 控制超时与取消
+func (m *TypedORM[T]) CountByContext(ctx context.Context, groupColumn string, queryParts ...any) (map[any]int64, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return m.load(new(T)).CountByContext(ctx, groupColumn, queryParts...)
+}
+
+// AggregateBy 按 groupColumn 分组计算 fn(column)
+func (m *TypedORM[T]) AggregateBy(fn support.Aggregate, column, groupColumn string, queryParts ...any) ([]support.Group, error) {
+	return m.AggregateByContext(context.Background(), fn, column, groupColumn, queryParts...)
+}
+
+// AggregateByContext 与 AggregateBy 相同,使用 ctx 控制超时与取消
+func (m *TypedORM[T]) AggregateByContext(ctx context.Context, fn support.Aggregate, column, groupColumn string, queryParts ...any) ([]support.Group, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return m.load(new(T)).AggregateByContext(ctx, fn, column, groupColumn, queryParts...)
+}
+
+// Create 插入 obj
+func (m *TypedORM[T]) Create(obj *T) error {
+	return m.CreateContext(context.Background(), obj)
+}
+
+// CreateContext 与 Create 相同,使用 ctx 控制超时与取消
+func (m *TypedORM[T]) CreateContext(ctx context.Context, obj *T) error {
+	if m.err != nil {
+		return m.err
+	}
+	return m.load(obj).CreateContext(ctx)
+}
+
+// CreateWithResult 与 Create 相同,同时返回受影响的行数、自增 ID 与执行的 SQL
+func (m *TypedORM[T]) CreateWithResult(obj *T) (support.Result, error) {
+	return m.CreateWithResultContext(context.Background(), obj)
+}
+
+// CreateWithResultContext 与 CreateWithResult 相同,使用 ctx 控制超时与取消
+func (m *TypedORM[T]) CreateWithResultContext(ctx context.Context, obj *T) (support.Result, error) {
+	if m.err != nil {
+		return support.Result{}, m.err
+	}
+	return m.load(obj).CreateWithResultContext(ctx)
+}
+
+// CreateBatch 批量插入 objs,自增字段会回写到 objs 的元素中
+func (m *TypedORM[T]) CreateBatch(objs []T, batchSize int) error {
+	return m.CreateBatchContext(context.Background(), objs, batchSize)
+}
+
+// CreateBatchContext 与 CreateBatch 相同,使用 ctx 控制超时与取消
+func (m *TypedORM[T]) CreateBatchContext(ctx context.Context, objs []T, batchSize int) error {
+	if m.err != nil {
+		return m.err
+	}
+	return m.load(new(T)).CreateBatchContext(ctx, objs, batchSize)
+}
+
+// Upsert 插入 obj,冲突时更新已有记录
+func (m *TypedORM[T]) Upsert(obj *T, conflictColumns ...string) error {
+	return m.UpsertContext(context.Background(), obj, conflictColumns...)
+}
+
+// UpsertContext 与 Upsert 相同,使用 ctx 控制超时与取消
+func (m *TypedORM[T]) UpsertContext(ctx context.Context, obj *T, conflictColumns ...string) error {
+	if m.err != nil {
+		return m.err
+	}
+	return m.load(obj).UpsertContext(ctx, conflictColumns...)
+}
+
+// Update 使用 obj 的非零值字段更新记录
+func (m *TypedORM[T]) Update(obj *T, queryParts ...any) error {
+	return m.UpdateContext(context.Background(), obj, queryParts...)
+}
+
+// UpdateContext 与 Update 相同,使用 ctx 控制超时与取消
+func (m *TypedORM[T]) UpdateContext(ctx context.Context, obj *T, queryParts ...any) error {
+	if m.err != nil {
+		return m.err
+	}
+	return m.load(obj).UpdateContext(ctx, queryParts...)
+}
+
+// UpdateWithResult 与 Update 相同,同时返回受影响的行数与执行的 SQL
+func (m *TypedORM[T]) UpdateWithResult(obj *T, queryParts ...any) (support.Result, error) {
+	return m.UpdateWithResultContext(context.Background(), obj, queryParts...)
+}
+
+// UpdateWithResultContext 与 UpdateWithResult 相同,使用 ctx 控制超时与取消
+func (m *TypedORM[T]) UpdateWithResultContext(ctx context.Context, obj *T, queryParts ...any) (support.Result, error) {
+	if m.err != nil {
+		return support.Result{}, m.err
+	}
+	return m.load(obj).UpdateWithResultContext(ctx, queryParts...)
+}
+
+// UpdateReturning 使用 obj 的非零值字段更新记录,并返回更新后的记录
+func (m *TypedORM[T]) UpdateReturning(obj *T, queryParts ...any) ([]T, error) {
+	return m.UpdateReturningContext(context.Background(), obj, queryParts...)
+}
+
+// UpdateReturningContext 与 UpdateReturning 相同,使用 ctx 控制超时与取消
+func (m *TypedORM[T]) UpdateReturningContext(ctx context.Context, obj *T, queryParts ...any) ([]T, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	results, err := m.load(obj).UpdateReturningContext(ctx, queryParts...)
+	return toSlice[T](results, err)
+}
+
+// UpdateColumns 按 values 更新字段,queryParts 为空时按 obj 的主键匹配,参见 support.ObjectORM.UpdateColumns
+func (m *TypedORM[T]) UpdateColumns(obj *T, values map[string]any, queryParts ...any) error {
+	return m.UpdateColumnsContext(context.Background(), obj, values, queryParts...)
+}
+
+// UpdateColumnsContext 与 UpdateColumns 相同,使用 ctx 控制超时与取消
+func (m *TypedORM[T]) UpdateColumnsContext(ctx context.Context, obj *T, values map[string]any, queryParts ...any) error {
+	if m.err != nil {
+		return m.err
+	}
+	return m.load(obj).UpdateColumnsContext(ctx, values, queryParts...)
+}
+
+// UpdateFields 按 obj 的主键更新 fields 字段,零值同样写入
+func (m *TypedORM[T]) UpdateFields(obj *T, fields ...string) error {
+	return m.UpdateFieldsContext(context.Background(), obj, fields...)
+}
+
+// UpdateFieldsContext 与 UpdateFields 相同,使用 ctx 控制超时与取消
+func (m *TypedORM[T]) UpdateFieldsContext(ctx context.Context, obj *T, fields ...string) error {
+	if m.err != nil {
+		return m.err
+	}
+	return m.load(obj).UpdateFieldsContext(ctx, fields...)
+}
+
+// Save 使用 obj 的所有字段更新记录
+func (m *TypedORM[T]) Save(obj *T, queryParts ...any) error {
+	return m.SaveContext(context.Background(), obj, queryParts...)
+}
+
+// SaveContext 与 Save 相同,使用 ctx 控制超时与取消
+func (m *TypedORM[T]) SaveContext(ctx context.Context, obj *T, queryParts ...any) error {
+	if m.err != nil {
+		return m.err
+	}
+	return m.load(obj).SaveContext(ctx, queryParts...)
+}
+
+// SaveWithResult 与 Save 相同,同时返回受影响的行数与执行的 SQL
+func (m *TypedORM[T]) SaveWithResult(obj *T, queryParts ...any) (support.Result, error) {
+	return m.SaveWithResultContext(context.Background(), obj, queryParts...)
+}
+
+// SaveWithResultContext 与 SaveWithResult 相同,使用 ctx 控制超时与取消
+func (m *TypedORM[T]) SaveWithResultContext(ctx context.Context, obj *T, queryParts ...any) (support.Result, error) {
+	if m.err != nil {
+		return support.Result{}, m.err
+	}
+	return m.load(obj).SaveWithResultContext(ctx, queryParts...)
+}
+
+// Delete 删除记录
+func (m *TypedORM[T]) Delete(obj *T, queryParts ...any) error {
+	return m.DeleteContext(context.Background(), obj, queryParts...)
+}
+
+// DeleteContext 与 Delete 相同,使用 ctx 控制超时与取消
+func (m *TypedORM[T]) DeleteContext(ctx context.Context, obj *T, queryParts ...any) error {
+	if m.err != nil {
+		return m.err
+	}
+	return m.load(obj).DeleteContext(ctx, queryParts...)
+}
+
+// DeleteWithResult 与 Delete 相同,同时返回受影响的行数与执行的 SQL
+func (m *TypedORM[T]) DeleteWithResult(obj *T, queryParts ...any) (support.Result, error) {
+	return m.DeleteWithResultContext(context.Background(), obj, queryParts...)
+}
+
+// DeleteWithResultContext 与 DeleteWithResult 相同,使用 ctx 控制超时与取消
+func (m *TypedORM[T]) DeleteWithResultContext(ctx context.Context, obj *T, queryParts ...any) (support.Result, error) {
+	if m.err != nil {
+		return support.Result{}, m.err
+	}
+	return m.load(obj).DeleteWithResultContext(ctx, queryParts...)
+}
+
+// DeleteReturning 删除记录并返回被删除的记录
+func (m *TypedORM[T]) DeleteReturning(queryParts ...any) ([]T, error) {
+	return m.DeleteReturningContext(context.Background(), queryParts...)
+}
+
+// DeleteReturningContext 与 DeleteReturning 相同,使用 ctx 控制超时与取消
+func (m *TypedORM[T]) DeleteReturningContext(ctx context.Context, queryParts ...any) ([]T, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	results, err := m.load(new(T)).DeleteReturningContext(ctx, queryParts...)
+	return toSlice[T](results, err)
+}
+
+// DeleteByPK 按主键删除记录
+func (m *TypedORM[T]) DeleteByPK(ids ...any) error {
+	return m.DeleteByPKContext(context.Background(), ids...)
+}
+
+// DeleteByPKContext 与 DeleteByPK 相同,使用 ctx 控制超时与取消
+func (m *TypedORM[T]) DeleteByPKContext(ctx context.Context, ids ...any) error {
+	if m.err != nil {
+		return m.err
+	}
+	return m.load(new(T)).DeleteByPKContext(ctx, ids...)
+}
+
+// toSlice 将 ObjectORM 返回的 []any 转换为 []T
+func toSlice[T any](results []any, err error) ([]T, error) {
+	if err != nil {
+		return nil, err
+	}
+	objs := make([]T, len(results))
+	for i := 0; i < len(results); i++ {
+		objs[i] = results[i].(T)
+	}
+	return objs, nil
+}
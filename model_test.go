@@ -0,0 +1,147 @@
+// Copyright 2024 OblivionOcean
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package opao_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/OblivionOcean/opao"
+	"github.com/OblivionOcean/opao/internal/fakedb"
+	"github.com/OblivionOcean/opao/support"
+	"github.com/OblivionOcean/opao/support/mysql"
+)
+
+type UserProfile struct {
+	Id   int64  `db:"id" option:"primaryKey;autoIncrement"`
+	Name string `db:"name"`
+}
+
+type Account struct {
+	Id    int64  `db:"id" option:"primaryKey;autoIncrement"`
+	Email string `db:"email"`
+}
+
+func (Account) TableName() string { return "accounts" }
+
+type Team struct {
+	Id int64 `db:"id" option:"primaryKey;autoIncrement"`
+}
+
+func (*Team) TableName() string { return "teams" }
+
+// newDB 创建使用 fakedb 与 MySQL 方言的 Database
+func newDB(t *testing.T) (*opao.Database, *fakedb.Driver) {
+	t.Helper()
+	d := &fakedb.Driver{}
+	db := &opao.Database{Conn: fakedb.Open(d)}
+	db.ORM.Init(db.Conn, mysql.NewMySQL)
+	db.Config().Dialect = support.DialectMySQL
+	t.Cleanup(func() { _ = db.Close() })
+	return db, d
+}
+
+func TestModelRegister(t *testing.T) {
+	cases := []struct {
+		name string
+		run  func(db *opao.Database) error
+		sql  string
+	}{
+		{"snake case", func(db *opao.Database) error {
+			_, err := opao.Model[UserProfile](db).FindAll()
+			return err
+		}, "SELECT `id`,`name` FROM `user_profile`"},
+		{"tabler", func(db *opao.Database) error {
+			_, err := opao.Model[Account](db).FindAll()
+			return err
+		}, "SELECT `id`,`email` FROM `accounts`"},
+		{"pointer tabler", func(db *opao.Database) error {
+			_, err := opao.Model[Team](db).FindAll()
+			return err
+		}, "SELECT `id` FROM `teams`"},
+		{"registered", func(db *opao.Database) error {
+			if err := db.Register("people", &UserProfile{}); err != nil {
+				return err
+			}
+			_, err := opao.Model[UserProfile](db).FindAll()
+			return err
+		}, "SELECT `id`,`name` FROM `people`"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			db, d := newDB(t)
+			if err := c.run(db); err != nil {
+				t.Fatal(err)
+			}
+			if got := d.Last().SQL; got != c.sql {
+				t.Errorf("sql:\n got %s\nwant %s", got, c.sql)
+			}
+		})
+	}
+}
+
+func TestModelNotStruct(t *testing.T) {
+	db, d := newDB(t)
+	m := opao.Model[int](db)
+	if m.Error() == nil {
+		t.Fatal("want error for non-struct model")
+	}
+	if _, err := m.FindAll(); err == nil {
+		t.Error("FindAll: want error for non-struct model")
+	}
+	if _, err := m.Find(); err == nil {
+		t.Error("Find: want error for non-struct model")
+	}
+	if len(d.Calls()) != 0 {
+		t.Fatalf("unexpected SQL: %v", d.Calls())
+	}
+}
+
+func TestModelFind(t *testing.T) {
+	db, d := newDB(t)
+	m := opao.Model[UserProfile](db)
+	if _, err := m.Find(opao.Eq("id", 1)); !errors.Is(err, opao.ErrRecordNotFound) {
+		t.Fatalf("got %v, want ErrRecordNotFound", err)
+	}
+
+	d.Query = func(query string, args []any) (fakedb.Rows, error) {
+		return fakedb.Rows{Columns: []string{"id", "name"}, Values: [][]any{{int64(1), "a"}}}, nil
+	}
+	u, err := m.Find(opao.Eq("id", 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *u != (UserProfile{Id: 1, Name: "a"}) {
+		t.Errorf("got %+v", *u)
+	}
+	if got, want := d.Last().SQL, "SELECT `id`,`name` FROM `user_profile` WHERE id = ?"; got != want {
+		t.Errorf("sql:\n got %s\nwant %s", got, want)
+	}
+}
+
+func TestModelFindAll(t *testing.T) {
+	db, d := newDB(t)
+	d.Query = func(query string, args []any) (fakedb.Rows, error) {
+		return fakedb.Rows{Columns: []string{"id", "name"}, Values: [][]any{{int64(1), "a"}, {int64(2), "b"}}}, nil
+	}
+	list, err := opao.Model[UserProfile](db).FindAll(opao.Asc("id"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []UserProfile{{1, "a"}, {2, "b"}}; !reflect.DeepEqual(list, want) {
+		t.Errorf("got %+v, want %+v", list, want)
+	}
+}
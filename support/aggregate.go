@@ -0,0 +1,147 @@
+// Copyright 2024 OblivionOcean
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package support
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/OblivionOcean/opao/utils"
+)
+
+// Aggregate 聚合函数
+type Aggregate string
+
+const (
+	COUNT Aggregate = "COUNT" // 计数
+	SUM   Aggregate = "SUM"   // 求和
+	AVG   Aggregate = "AVG"   // 平均值
+	MIN   Aggregate = "MIN"   // 最小值
+	MAX   Aggregate = "MAX"   // 最大值
+)
+
+// Group 分组聚合的一行结果
+type Group struct {
+	Key   any // 分组字段的值,类型与字段相同,NULL 时为 nil
+	Value any // 聚合值:COUNT 为 int64,SUM/AVG 为 float64,MIN/MAX 与字段类型相同,NULL 时为 nil
+}
+
+// AggregateSQL 生成聚合查询语句,使用 ? 占位符,quote 为方言的标识符引号,tables 的第一张为主表
+// groupColumn 为空时生成单值聚合,q 中不能包含 GROUP BY,ORDER BY 被忽略;
+// 否则按 groupColumn 分组,每行依次为分组值与聚合值。column 与 groupColumn 必须已注册,
+// COUNT 的 column 可以为空或 "*";SUM 使用 COALESCE 保证空结果集返回 0
+func AggregateSQL(tables []Join, elems []Elem, quote byte, fn Aggregate, column, groupColumn string, q *Query) (string, error) {
+	switch fn {
+	case COUNT, SUM, AVG, MIN, MAX:
+	default:
+		return "", errors.New("unknown aggregate function: " + string(fn))
+	}
+	if !(fn == COUNT && (column == "" || column == "*")) && IndexElem(elems, column) == -1 {
+		return "", errors.New("unknown aggregate column: " + column)
+	}
+	if q.GroupBy != "" {
+		return "", errors.New("GROUP BY is not supported in aggregate queries, use AggregateBy")
+	}
+	if len(q.Sets) > 0 {
+		return "", errors.New("set operations are not supported in aggregate queries")
+	}
+
+	buf := utils.NewBuffer(48 + len(tables[0].Table) + len(column) + len(groupColumn) + q.Len())
+	buf.WriteString(q.With)
+	buf.WriteString("SELECT ")
+	if groupColumn != "" {
+		if IndexElem(elems, groupColumn) == -1 {
+			return "", errors.New("unknown group by column: " + groupColumn)
+		}
+		group := utils.NewBuffer(len(groupColumn) + 4)
+		writeColumn(&group, quote, groupColumn)
+		buf.WriteString(group.String())
+		buf.WriteByte(',')
+		q.GroupBy = group.String()
+	} else {
+		q.OrderBy = "" // 单值聚合不需要排序
+	}
+	if fn == SUM {
+		buf.WriteString("COALESCE(SUM(")
+	} else {
+		buf.WriteString(string(fn))
+		buf.WriteByte('(')
+	}
+	if column == "" || column == "*" {
+		buf.WriteByte('*')
+	} else {
+		writeColumn(&buf, quote, column)
+	}
+	if fn == SUM {
+		buf.WriteString("),0)")
+	} else {
+		buf.WriteByte(')')
+	}
+	WriteFrom(&buf, quote, tables)
+	q.WriteTo(&buf)
+	return buf.String(), nil
+}
+
+// ScanGroups 扫描分组聚合结果,分组值按 elems 中 groupColumn 字段的类型扫描
+func ScanGroups(rows *sql.Rows, elems []Elem, fn Aggregate, column, groupColumn string) ([]Group, error) {
+	keyType := elems[IndexElem(elems, groupColumn)].Type
+	var valueType reflect.Type
+	switch fn {
+	case COUNT:
+		valueType = reflect.TypeOf(int64(0))
+	case SUM, AVG:
+		valueType = reflect.TypeOf(float64(0))
+	default:
+		valueType = elems[IndexElem(elems, column)].Type
+	}
+
+	var groups []Group
+	for rows.Next() {
+		// 扫描到指针的指针,NULL 时保持为 nil
+		key := reflect.New(reflect.PointerTo(keyType))
+		value := reflect.New(reflect.PointerTo(valueType))
+		if err := rows.Scan(key.Interface(), value.Interface()); err != nil {
+			return nil, err
+		}
+		groups = append(groups, Group{Key: derefValue(key), Value: derefValue(value)})
+	}
+	return groups, TranslateError(rows.Err())
+}
+
+// derefValue 返回 **T 指向的值,为 nil 时返回 nil
+func derefValue(v reflect.Value) any {
+	if v.Elem().IsNil() {
+		return nil
+	}
+	return v.Elem().Elem().Interface()
+}
+
+// MapKey 将分组值转换为可作为 map 键的值
+// []byte 等字节切片转换为 string,其他不可比较的值(切片、map 等)转换为其字符串形式
+func MapKey(key any) any {
+	if key == nil {
+		return nil
+	}
+	v := reflect.ValueOf(key)
+	if v.Comparable() {
+		return key
+	}
+	if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+		return string(v.Bytes())
+	}
+	return fmt.Sprint(key)
+}
@@ -0,0 +1,47 @@
+// Copyright 2024 OblivionOcean
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package support
+
+import (
+	"database/sql"
+	"testing"
+)
+
+func TestMapKey(t *testing.T) {
+	type hash []byte
+	cases := []struct {
+		name string
+		key  any
+		want any
+	}{
+		{"nil", nil, nil},
+		{"int", int64(1), int64(1)},
+		{"string", "a", "a"},
+		{"bytes", []byte("a"), "a"},
+		{"named bytes", hash("a"), "a"},
+		{"raw bytes", sql.RawBytes("a"), "a"},
+		{"other slice", []int{1, 2}, "[1 2]"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := MapKey(c.key)
+			if got != c.want {
+				t.Errorf("got %#v, want %#v", got, c.want)
+			}
+			// 结果必须可以作为 map 的键
+			_ = map[any]bool{got: true}
+		})
+	}
+}
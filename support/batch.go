@@ -0,0 +1,73 @@
+// Copyright 2024 OblivionOcean
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package support
+
+import (
+	"errors"
+	"reflect"
+	"time"
+	"unsafe"
+)
+
+// BatchRows 校验批量操作的对象列表并返回每个对象的地址
+// objs 必须是 objType 结构体或其指针的切片(也可以是指向该切片的指针),指针元素不能为 nil
+func BatchRows(objs any, objType reflect.Type) ([]unsafe.Pointer, error) {
+	list := reflect.ValueOf(objs)
+	if list.Kind() == reflect.Ptr {
+		list = list.Elem()
+	}
+	if list.Kind() != reflect.Slice && list.Kind() != reflect.Array {
+		return nil, errors.New("objs must be a slice of registered structs")
+	}
+	elemType := list.Type().Elem()
+	isPtr := elemType.Kind() == reflect.Ptr
+	if isPtr {
+		elemType = elemType.Elem()
+	}
+	if elemType != objType {
+		return nil, errors.New("objs element type does not match the loaded object")
+	}
+
+	length := list.Len()
+	rows := make([]unsafe.Pointer, length)
+	for i := 0; i < length; i++ {
+		item := list.Index(i)
+		if isPtr {
+			if item.IsNil() {
+				return nil, errors.New("objs contains nil pointer")
+			}
+			rows[i] = item.UnsafePointer()
+			continue
+		}
+		if !item.CanAddr() {
+			return nil, errors.New("objs must be addressable, pass a slice or a pointer to an array")
+		}
+		rows[i] = item.Addr().UnsafePointer()
+	}
+	return rows, nil
+}
+
+// ValueSize 估算参数在协议中占用的字节数,用于按数据包大小拆分批量语句
+func ValueSize(val any) int {
+	switch v := val.(type) {
+	case string:
+		return len(v) + 9
+	case []byte:
+		return len(v) + 9
+	case time.Time:
+		return 12
+	}
+	return 9
+}
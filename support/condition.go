@@ -40,6 +40,11 @@ const (
 	NOT_IN_VALUES                            // NOT IN値列表条件
 	LIMIT                                    // LIMIT条件
 	CUSTOM                                   // 自定义条件
+	ORDER_BY                                 // ORDER BY子句
+	GROUP_BY                                 // GROUP BY子句
+	HAVING                                   // HAVING子句
+	AFTER                                    // 游标分页条件
+	SET                                      // UPDATE 赋值
 	UNKNOWN                                  // 未知条件类型
 )
 
@@ -50,3 +55,12 @@ type Condition struct {
 	Left  any
 	Right any
 }
+
+// IsClause 判断条件是否为子句条件(GROUP BY、HAVING、ORDER BY、LIMIT、依赖 ORDER BY 的 AFTER 与 Update 的 SET)
+func (cond Condition) IsClause() bool {
+	switch cond.Type {
+	case ORDER_BY, GROUP_BY, HAVING, LIMIT, AFTER, SET:
+		return true
+	}
+	return false
+}
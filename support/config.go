@@ -0,0 +1,140 @@
+// Copyright 2024 OblivionOcean
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package support
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Config 数据库级别的 ORM 配置,由同一个 ORM 加载出的所有 ObjectORM 共享
+type Config struct {
+	Timeout     time.Duration // 调用方传入的 context 没有截止时间时使用的默认超时,0 表示不限制
+	AllowGlobal bool          // 允许没有条件的 Update/Save/Delete 写入全表
+	Dialect     Dialect       // 数据库方言,用于将原生 SQL 的命名参数展开为对应的占位符
+
+	mu     sync.Mutex
+	server map[string]string // 服务器信息缓存,键为查询语句
+	models *SafeCache        // 已注册的模型,用于 JOIN 时查找其他模型
+}
+
+// Context 为一次数据库操作派生 context
+// 仅当 ctx 未设置截止时间且配置了默认超时时才附加超时
+// 返回的 cancel 必须在操作结束(包括 rows 关闭)后调用
+func (c *Config) Context(ctx context.Context) (context.Context, context.CancelFunc) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if c == nil || c.Timeout <= 0 {
+		return ctx, func() {}
+	}
+	if _, ok := ctx.Deadline(); ok {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, c.Timeout)
+}
+
+// StreamContext 为逐行遍历的查询派生 context
+// 仅当 ctx 未设置截止时间且配置了默认超时时,默认超时作用于查询开始执行到返回结果集之前;
+// 返回的 started 在得到结果集后调用,停止计时,之后的遍历不受默认超时限制
+// 返回的 cancel 必须在遍历结束(包括 rows 关闭)后调用
+func (c *Config) StreamContext(ctx context.Context) (_ context.Context, started func(), cancel context.CancelFunc) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx, cancel = context.WithCancel(ctx)
+	if c == nil || c.Timeout <= 0 {
+		return ctx, func() {}, cancel
+	}
+	if _, ok := ctx.Deadline(); ok {
+		return ctx, func() {}, cancel
+	}
+	timer := time.AfterFunc(c.Timeout, cancel)
+	return ctx, func() { timer.Stop() }, cancel
+}
+
+// Model 返回已注册模型的表名与字段信息,object 可以是结构体或结构体指针
+func (c *Config) Model(object any) (Cache, error) {
+	objType := reflect.TypeOf(object)
+	if objType != nil && objType.Kind() == reflect.Ptr {
+		objType = objType.Elem()
+	}
+	if c == nil || c.models == nil || objType == nil {
+		return Cache{}, errors.New("object not registered")
+	}
+	cache, ok := c.models.Load(objType)
+	if !ok {
+		return Cache{}, errors.New("object not registered")
+	}
+	return cache, nil
+}
+
+// ServerInfo 执行返回单个值的查询(如 SELECT VERSION())并缓存结果
+// 用于获取版本号、参数上限等在连接生命周期内不变的服务器信息,查询失败时不缓存
+func (c *Config) ServerInfo(ctx context.Context, conn Executor, query string) (string, error) {
+	c.mu.Lock()
+	if info, ok := c.server[query]; ok {
+		c.mu.Unlock()
+		return info, nil
+	}
+	c.mu.Unlock()
+
+	var info string
+	if err := conn.QueryRowContext(ctx, query).Scan(&info); err != nil {
+		return "", err
+	}
+
+	c.mu.Lock()
+	if c.server == nil {
+		c.server = make(map[string]string)
+	}
+	c.server[query] = info
+	c.mu.Unlock()
+	return info, nil
+}
+
+// MariaDBVersion 判断 VERSION() 返回的 version 是否为 MariaDB,是时返回去掉兼容前缀后的版本号
+// MariaDB 的版本号形如 "10.11.6-MariaDB-1:10.11.6+maria~ubu2204",经复制协议时可能带有 "5.5.5-" 前缀
+func MariaDBVersion(version string) (string, bool) {
+	if !strings.Contains(version, "MariaDB") {
+		return version, false
+	}
+	return strings.TrimPrefix(version, "5.5.5-"), true
+}
+
+// VersionAtLeast 判断版本号 version 是否不低于 parts 指定的版本
+// version 形如 "8.0.31"、"3.45.1" 或 "10.11.6-MariaDB",只比较开头的数字部分
+func VersionAtLeast(version string, parts ...int) bool {
+	for i := 0; i < len(parts); i++ {
+		end := 0
+		for end < len(version) && version[end] >= '0' && version[end] <= '9' {
+			end++
+		}
+		n, _ := strconv.Atoi(version[:end])
+		if n != parts[i] {
+			return n > parts[i]
+		}
+		if end < len(version) && version[end] == '.' {
+			end++
+		}
+		version = version[end:]
+	}
+	return true
+}
@@ -0,0 +1,75 @@
+// Copyright 2024 OblivionOcean
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package support
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/OblivionOcean/opao/utils"
+)
+
+// CTE 公用表表达式,渲染为 WITH 子句中的一项
+type CTE struct {
+	Name      string // 名称,可以带字段列表,如 tree(id,parent_id)
+	SQL       string // 查询语句,使用 ? 占位符
+	Args      []any  // 查询参数
+	Recursive bool   // 是否为递归查询
+}
+
+// NewCTE 创建公用表表达式,subquery 可以是 SQL 字符串、带参数的 Custom 条件或 Subquery
+func NewCTE(name string, subquery any, recursive bool, config *Config, render SubqueryRenderer) (CTE, error) {
+	if name == "" {
+		return CTE{}, errors.New("CTE name required")
+	}
+	sqlStr, args, err := resolveSubquery(subquery, nil, config, render)
+	if err != nil {
+		return CTE{}, err
+	}
+	return CTE{Name: name, SQL: sqlStr, Args: args, Recursive: recursive}, nil
+}
+
+// WithClause 渲染 WITH 子句,包含末尾的空格;ctes 为空时返回空字符串
+// 任一项为递归查询时使用 WITH RECURSIVE,参数按各项顺序排列
+func WithClause(quote byte, ctes []CTE) (string, []any) {
+	if len(ctes) == 0 {
+		return "", nil
+	}
+	buf := utils.NewBuffer(64)
+	buf.WriteString("WITH ")
+	for i := 0; i < len(ctes); i++ {
+		if ctes[i].Recursive {
+			buf.WriteString("RECURSIVE ")
+			break
+		}
+	}
+	var args []any
+	for i := 0; i < len(ctes); i++ {
+		name := ctes[i].Name
+		if end := strings.IndexByte(name, '('); end != -1 {
+			writeColumn(&buf, quote, strings.TrimSpace(name[:end]))
+			buf.WriteString(name[end:])
+		} else {
+			writeColumn(&buf, quote, name)
+		}
+		buf.WriteString(" AS (")
+		buf.WriteString(ctes[i].SQL)
+		buf.WriteString("),")
+		args = append(args, ctes[i].Args...)
+	}
+	buf.TruncateLast(1) // 移除末尾的逗号
+	buf.WriteByte(' ')
+	return buf.String(), args
+}
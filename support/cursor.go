@@ -0,0 +1,145 @@
+// Copyright 2024 OblivionOcean
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package support
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"reflect"
+
+	"github.com/OblivionOcean/opao/utils"
+)
+
+// cursor 游标的编码内容,记录排序字段及最后一行在这些字段上的值
+type cursor struct {
+	Columns []string          `json:"c"`
+	Values  []json.RawMessage `json:"v"`
+}
+
+// EncodeCursor 根据排序字段将 obj 编码为不透明的游标字符串
+// obj 为 elems 所属类型的结构体或其指针,通常是上一页的最后一行
+func EncodeCursor(elems []Elem, order []OrderColumn, obj any) (string, error) {
+	if len(order) == 0 {
+		return "", errors.New("cursor pagination requires ORDER BY")
+	}
+	val := reflect.ValueOf(obj)
+	if val.Kind() != reflect.Pointer {
+		ptr := reflect.New(val.Type())
+		ptr.Elem().Set(val)
+		val = ptr
+	}
+	base := val.UnsafePointer()
+
+	c := cursor{Columns: make([]string, len(order)), Values: make([]json.RawMessage, len(order))}
+	for i := 0; i < len(order); i++ {
+		index := IndexElem(elems, order[i].Column)
+		if index == -1 {
+			return "", errors.New("unknown order by column: " + order[i].Column)
+		}
+		elem := elems[index].At(base)
+		raw, err := json.Marshal(elem.Get())
+		if err != nil {
+			return "", err
+		}
+		c.Columns[i], c.Values[i] = order[i].Column, raw
+	}
+	raw, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+// ApplyCursor 将 q.After 中的游标转换为键集条件并与 WHERE 条件组合
+// 游标记录的字段必须与 ORDER BY 字段一致,升序字段取大于游标值的行,降序字段取小于游标值的行
+func ApplyCursor(q *Query, elems []Elem, quote byte) error {
+	if q.After == "" {
+		return nil
+	}
+	if len(q.Order) == 0 {
+		return errors.New("cursor pagination requires ORDER BY")
+	}
+	values, err := decodeCursor(q.After, elems, q.Order)
+	if err != nil {
+		return err
+	}
+
+	// (a > ?) OR (a = ? AND b > ?) ...
+	buf := utils.NewBuffer(len(q.Where) + len(q.Order)*len(q.Order)*24)
+	args := make([]any, 0, len(q.WhereArgs)+len(q.Order)*(len(q.Order)+1)/2)
+	if q.Where != "" {
+		buf.WriteByte('(')
+		buf.WriteString(q.Where)
+		buf.WriteString(") AND (")
+		args = append(args, q.WhereArgs...)
+	}
+	for i := 0; i < len(q.Order); i++ {
+		if i > 0 {
+			buf.WriteString(" OR ")
+		}
+		buf.WriteByte('(')
+		for j := 0; j < i; j++ {
+			writeColumn(&buf, quote, q.Order[j].Column)
+			buf.WriteString(" = ? AND ")
+			args = append(args, values[j])
+		}
+		writeColumn(&buf, quote, q.Order[i].Column)
+		if q.Order[i].Desc {
+			buf.WriteString(" < ?")
+		} else {
+			buf.WriteString(" > ?")
+		}
+		args = append(args, values[i])
+		buf.WriteByte(')')
+	}
+	if q.Where != "" {
+		buf.WriteByte(')')
+	}
+	q.Where, q.WhereArgs, q.After = buf.String(), args, ""
+	return nil
+}
+
+// decodeCursor 解码游标并按字段类型还原各排序字段的值
+func decodeCursor(s string, elems []Elem, order []OrderColumn) ([]any, error) {
+	invalid := errors.New("invalid cursor")
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, invalid
+	}
+	var c cursor
+	if err = json.Unmarshal(raw, &c); err != nil || len(c.Columns) != len(c.Values) {
+		return nil, invalid
+	}
+	if len(c.Columns) != len(order) {
+		return nil, errors.New("cursor does not match ORDER BY")
+	}
+	values := make([]any, len(order))
+	for i := 0; i < len(order); i++ {
+		if c.Columns[i] != order[i].Column {
+			return nil, errors.New("cursor does not match ORDER BY")
+		}
+		index := IndexElem(elems, order[i].Column)
+		if index == -1 {
+			return nil, errors.New("unknown order by column: " + order[i].Column)
+		}
+		val := reflect.New(elems[index].Type)
+		if err = json.Unmarshal(c.Values[i], val.Interface()); err != nil {
+			return nil, invalid
+		}
+		values[i] = val.Elem().Interface()
+	}
+	return values, nil
+}
@@ -141,6 +141,27 @@ func (elem *Elem) Set(val any) error {
 	return nil
 }
 
+// At 返回绑定到 base 所指对象的 Elem 副本
+// base 必须指向 Elem 所属类型的结构体,用于在同一类型的多个对象间复用字段信息
+func (elem Elem) At(base unsafe.Pointer) Elem {
+	elem.Ptr = unsafe.Add(base, elem.Offset)
+	return elem
+}
+
+// SetInt64 将 v 写入整型字段,用于回写自增主键等数据库生成的整数
+func (elem *Elem) SetInt64(v int64) error {
+	val := reflect.NewAt(elem.Type, elem.Ptr).Elem()
+	switch val.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		val.SetInt(v)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		val.SetUint(uint64(v))
+	default:
+		return errors.New("field is not an integer")
+	}
+	return nil
+}
+
 func (elem *Elem) GetInterface() any {
 	return reflect.NewAt(elem.Type, elem.Ptr).Interface()
 }
@@ -189,3 +210,30 @@ func (elem *Elem) Zero() bool {
 
 	return reflect.NewAt(elem.Type, ptr).Elem().IsZero()
 }
+
+// Bind 返回绑定到 base 所指对象的字段元素副本,base 必须指向 c.ObjType 类型的结构体
+func (c Cache) Bind(base unsafe.Pointer) []Elem {
+	elems := make([]Elem, len(c.Elems))
+	for i := 0; i < len(c.Elems); i++ {
+		elems[i] = c.Elems[i].At(base)
+	}
+	return elems
+}
+
+// IndexElem 返回 tag 对应字段在 elems 中的下标,不存在时返回 -1
+func IndexElem(elems []Elem, tag string) int {
+	for i := 0; i < len(elems); i++ {
+		if elems[i].Tag == tag {
+			return i
+		}
+	}
+	return -1
+}
+
+// BindScans 将 scans 绑定为 base 所指对象中各字段的扫描目标
+// base 必须指向 elems 所属类型的结构体,scans 长度需与 elems 一致
+func BindScans(scans []any, elems []Elem, base unsafe.Pointer) {
+	for i := 0; i < len(elems); i++ {
+		scans[i] = reflect.NewAt(elems[i].Type, unsafe.Add(base, elems[i].Offset)).Interface()
+	}
+}
@@ -0,0 +1,190 @@
+// Copyright 2024 OblivionOcean
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package support
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrNoPrimaryKey  = errors.New("primary key not defined")
+	ErrMissingWhere  = errors.New("missing WHERE condition, use AllowGlobal to update or delete all rows")
+	ErrWriteClause   = errors.New("GROUP BY, HAVING, ORDER BY and LIMIT are not supported in write operations")
+	ErrLockOutsideTx = errors.New("row locks can only be used inside a transaction")
+
+	// ErrRecordNotFound 查询单条记录没有结果,errors.Is 同时与 sql.ErrNoRows 匹配
+	ErrRecordNotFound = fmt.Errorf("record not found: %w", sql.ErrNoRows)
+
+	ErrDuplicateKey        = errors.New("duplicate key")
+	ErrForeignKeyViolation = errors.New("foreign key violation")
+	ErrNotNullViolation    = errors.New("not null violation")
+	ErrDeadlock            = errors.New("deadlock")
+	ErrSerialization       = errors.New("serialization failure")
+)
+
+// DBError 由数据库驱动返回的错误转换而来
+// errors.Is 与 Kind 对应的哨兵错误匹配,errors.As 可继续取得驱动原始的错误类型
+type DBError struct {
+	Kind error  // ErrDuplicateKey 等哨兵错误
+	Code string // MySQL 的错误号、PostgreSQL 的 SQLSTATE 或 SQLite 的扩展错误码,由错误信息识别且信息中没有错误码时为空
+	Err  error  // 驱动返回的原始错误
+}
+
+func (e *DBError) Error() string {
+	return e.Kind.Error() + ": " + e.Err.Error()
+}
+
+func (e *DBError) Is(target error) bool {
+	return target == e.Kind
+}
+
+func (e *DBError) Unwrap() error {
+	return e.Err
+}
+
+var (
+	// mysqlErrors MySQL 错误号
+	mysqlErrors = map[int64]error{
+		1062: ErrDuplicateKey,        // ER_DUP_ENTRY
+		1586: ErrDuplicateKey,        // ER_DUP_ENTRY_WITH_KEY_NAME
+		1216: ErrForeignKeyViolation, // ER_NO_REFERENCED_ROW
+		1217: ErrForeignKeyViolation, // ER_ROW_IS_REFERENCED
+		1451: ErrForeignKeyViolation, // ER_ROW_IS_REFERENCED_2
+		1452: ErrForeignKeyViolation, // ER_NO_REFERENCED_ROW_2
+		1048: ErrNotNullViolation,    // ER_BAD_NULL_ERROR
+		1213: ErrDeadlock,            // ER_LOCK_DEADLOCK
+	}
+	// pgErrors PostgreSQL SQLSTATE
+	pgErrors = map[string]error{
+		"23505": ErrDuplicateKey,        // unique_violation
+		"23503": ErrForeignKeyViolation, // foreign_key_violation
+		"23502": ErrNotNullViolation,    // not_null_violation
+		"40P01": ErrDeadlock,            // deadlock_detected
+		"40001": ErrSerialization,       // serialization_failure
+	}
+	// sqliteErrors SQLite 扩展错误码
+	sqliteErrors = map[int64]error{
+		2067: ErrDuplicateKey,        // SQLITE_CONSTRAINT_UNIQUE
+		1555: ErrDuplicateKey,        // SQLITE_CONSTRAINT_PRIMARYKEY
+		787:  ErrForeignKeyViolation, // SQLITE_CONSTRAINT_FOREIGNKEY
+		1299: ErrNotNullViolation,    // SQLITE_CONSTRAINT_NOTNULL
+		517:  ErrSerialization,       // SQLITE_BUSY_SNAPSHOT
+	}
+	// messageErrors 无法取得错误码时按错误信息识别
+	messageErrors = []struct {
+		text string
+		kind error
+	}{
+		{"Duplicate entry", ErrDuplicateKey},
+		{"duplicate key value violates unique constraint", ErrDuplicateKey},
+		{"UNIQUE constraint failed", ErrDuplicateKey},
+		{"a foreign key constraint fails", ErrForeignKeyViolation},
+		{"violates foreign key constraint", ErrForeignKeyViolation},
+		{"FOREIGN KEY constraint failed", ErrForeignKeyViolation},
+		{"cannot be null", ErrNotNullViolation},
+		{"violates not-null constraint", ErrNotNullViolation},
+		{"NOT NULL constraint failed", ErrNotNullViolation},
+		{"Deadlock found", ErrDeadlock},
+		{"deadlock detected", ErrDeadlock},
+		{"could not serialize access", ErrSerialization},
+	}
+)
+
+// TranslateError 将驱动返回的约束冲突、死锁等错误转换为 *DBError
+// 不依赖驱动包:依次通过 SQLState()、Code() 方法与 Number、Code、ExtendedCode 字段读取错误码,
+// 均不可用时按错误信息识别;无法识别的错误、sql.ErrNoRows 与 nil 原样返回
+func TranslateError(err error) error {
+	if err == nil || errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	var dbErr *DBError
+	if errors.As(err, &dbErr) {
+		return err
+	}
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		if code, kind := errorCode(e); kind != nil {
+			return &DBError{Kind: kind, Code: code, Err: err}
+		}
+	}
+	if code, kind := errorMessage(err.Error()); kind != nil {
+		return &DBError{Kind: kind, Code: code, Err: err}
+	}
+	return err
+}
+
+// errorCode 从驱动的错误类型读取错误码
+// lib/pq 与 pgx 提供 SQLState(),modernc.org/sqlite 提供 Code();
+// go-sql-driver/mysql 的 Number、lib/pq 的 Code 与 mattn/go-sqlite3 的 ExtendedCode 通过反射读取
+func errorCode(err error) (string, error) {
+	if e, ok := err.(interface{ SQLState() string }); ok {
+		code := e.SQLState()
+		return code, pgErrors[code]
+	}
+	if e, ok := err.(interface{ Code() int }); ok {
+		code := int64(e.Code())
+		return strconv.FormatInt(code, 10), sqliteErrors[code]
+	}
+
+	val := reflect.ValueOf(err)
+	if val.Kind() == reflect.Pointer {
+		val = val.Elem()
+	}
+	if val.Kind() != reflect.Struct {
+		return "", nil
+	}
+	if f := val.FieldByName("Number"); f.IsValid() && f.CanUint() {
+		code := int64(f.Uint())
+		return strconv.FormatInt(code, 10), mysqlErrors[code]
+	}
+	if f := val.FieldByName("ExtendedCode"); f.IsValid() && f.CanInt() {
+		code := f.Int()
+		return strconv.FormatInt(code, 10), sqliteErrors[code]
+	}
+	if f := val.FieldByName("Code"); f.IsValid() && f.Kind() == reflect.String {
+		code := f.String()
+		return code, pgErrors[code]
+	}
+	return "", nil
+}
+
+// errorMessage 按错误信息识别错误
+// 信息中包含 "Error 1062" 形式的 MySQL 错误号或 "SQLSTATE 23505" 形式的 SQLSTATE 时按错误码识别
+func errorMessage(msg string) (string, error) {
+	if i := strings.Index(msg, "Error "); i != -1 {
+		end := i + 6
+		for end < len(msg) && msg[end] >= '0' && msg[end] <= '9' {
+			end++
+		}
+		if code, err := strconv.ParseInt(msg[i+6:end], 10, 64); err == nil && mysqlErrors[code] != nil {
+			return msg[i+6 : end], mysqlErrors[code]
+		}
+	}
+	if i := strings.Index(msg, "SQLSTATE "); i != -1 && i+14 <= len(msg) {
+		if code := msg[i+9 : i+14]; pgErrors[code] != nil {
+			return code, pgErrors[code]
+		}
+	}
+	for i := 0; i < len(messageErrors); i++ {
+		if strings.Contains(msg, messageErrors[i].text) {
+			return "", messageErrors[i].kind
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,134 @@
+// Copyright 2024 OblivionOcean
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package support
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// 以下类型模拟各驱动的错误类型,只保留 TranslateError 读取的字段与方法
+
+// mysqlError 模拟 go-sql-driver/mysql 的 *MySQLError
+type mysqlError struct {
+	Number  uint16
+	Message string
+}
+
+func (e *mysqlError) Error() string { return fmt.Sprintf("Error %d: %s", e.Number, e.Message) }
+
+// pqErrorCode 模拟 lib/pq 的 ErrorCode
+type pqErrorCode string
+
+// pqError 模拟 lib/pq 的 *Error
+type pqError struct {
+	Code    pqErrorCode
+	Message string
+}
+
+func (e *pqError) Error() string { return "pq: " + e.Message }
+
+// pgxError 模拟 pgx 的 *PgError
+type pgxError struct{ code string }
+
+func (e *pgxError) Error() string    { return "ERROR: " + e.code }
+func (e *pgxError) SQLState() string { return e.code }
+
+// sqlite3Error 模拟 mattn/go-sqlite3 的 Error
+type sqlite3Error struct {
+	Code         int
+	ExtendedCode int
+}
+
+func (e sqlite3Error) Error() string { return "sqlite3 error" }
+
+// moderncError 模拟 modernc.org/sqlite 的 *Error
+type moderncError struct{ code int }
+
+func (e *moderncError) Error() string { return "sqlite error" }
+func (e *moderncError) Code() int     { return e.code }
+
+func TestTranslateError(t *testing.T) {
+	cases := []struct {
+		name string
+		err  error
+		kind error
+		code string
+	}{
+		{"mysql duplicate", &mysqlError{Number: 1062, Message: "Duplicate entry 'a' for key 'name'"}, ErrDuplicateKey, "1062"},
+		{"mysql foreign key", &mysqlError{Number: 1452}, ErrForeignKeyViolation, "1452"},
+		{"mysql not null", &mysqlError{Number: 1048}, ErrNotNullViolation, "1048"},
+		{"mysql deadlock", &mysqlError{Number: 1213}, ErrDeadlock, "1213"},
+		{"pq duplicate", &pqError{Code: "23505"}, ErrDuplicateKey, "23505"},
+		{"pq serialization", &pqError{Code: "40001"}, ErrSerialization, "40001"},
+		{"pgx foreign key", &pgxError{code: "23503"}, ErrForeignKeyViolation, "23503"},
+		{"pgx deadlock", &pgxError{code: "40P01"}, ErrDeadlock, "40P01"},
+		{"sqlite3 unique", sqlite3Error{Code: 19, ExtendedCode: 2067}, ErrDuplicateKey, "2067"},
+		{"sqlite3 primary key", sqlite3Error{Code: 19, ExtendedCode: 1555}, ErrDuplicateKey, "1555"},
+		{"sqlite3 not null", sqlite3Error{Code: 19, ExtendedCode: 1299}, ErrNotNullViolation, "1299"},
+		{"modernc foreign key", &moderncError{code: 787}, ErrForeignKeyViolation, "787"},
+		{"wrapped", fmt.Errorf("insert user: %w", &mysqlError{Number: 1062}), ErrDuplicateKey, "1062"},
+		{"message mysql code", errors.New("Error 1062 (23000): Duplicate entry 'a'"), ErrDuplicateKey, "1062"},
+		{"message sqlstate", errors.New("ERROR: duplicate (SQLSTATE 23505)"), ErrDuplicateKey, "23505"},
+		{"message text", errors.New("NOT NULL constraint failed: user.name"), ErrNotNullViolation, ""},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := TranslateError(c.err)
+			if !errors.Is(err, c.kind) {
+				t.Fatalf("got %v, want %v", err, c.kind)
+			}
+			var dbErr *DBError
+			if !errors.As(err, &dbErr) {
+				t.Fatalf("got %T, want *DBError", err)
+			}
+			if dbErr.Code != c.code {
+				t.Errorf("code: got %q, want %q", dbErr.Code, c.code)
+			}
+			if !errors.Is(err, c.err) {
+				t.Errorf("original error is not wrapped: %v", err)
+			}
+			// 已转换的错误不再重复包装
+			if again := TranslateError(err); again != err {
+				t.Errorf("translated twice: %v", again)
+			}
+		})
+	}
+}
+
+func TestTranslateErrorPassThrough(t *testing.T) {
+	other := &mysqlError{Number: 1045, Message: "Access denied"}
+	for _, err := range []error{nil, sql.ErrNoRows, ErrRecordNotFound, other, errors.New("connection refused"), &pgxError{code: "42P01"}} {
+		if got := TranslateError(err); got != err {
+			t.Errorf("TranslateError(%v) = %v, want the error unchanged", err, got)
+		}
+	}
+}
+
+func TestTranslateErrorAs(t *testing.T) {
+	err := TranslateError(&mysqlError{Number: 1062, Message: "Duplicate entry"})
+	var driverErr *mysqlError
+	if !errors.As(err, &driverErr) || driverErr.Number != 1062 {
+		t.Fatalf("errors.As failed: %v", err)
+	}
+	if errors.Is(err, ErrDeadlock) {
+		t.Errorf("duplicate key error matches ErrDeadlock")
+	}
+	if want := "duplicate key: Error 1062: Duplicate entry"; err.Error() != want {
+		t.Errorf("got %q, want %q", err.Error(), want)
+	}
+}
@@ -0,0 +1,257 @@
+// Copyright 2024 OblivionOcean
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package support
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/OblivionOcean/opao/utils"
+)
+
+// Dialect 数据库方言,用于渲染与方言相关的表达式
+type Dialect int
+
+const (
+	DialectMySQL    Dialect = iota + 1 // MySQL
+	DialectPostgres                    // PostgreSQL
+	DialectSQLite                      // SQLite3
+)
+
+// quote 返回方言的标识符引号
+func (d Dialect) quote() byte {
+	if d == DialectMySQL {
+		return '`'
+	}
+	return '"'
+}
+
+// Expr SQL 表达式,可以作为 Set 的值或通过 SelectExpr 出现在字段列表中
+// 参数中的 Expr 按顺序展开到对应的 ? 占位符处,其余参数以占位符绑定
+type Expr struct {
+	SQL   string // 表达式,使用 ? 占位符
+	Args  []any  // 表达式参数
+	Alias string // 字段列表中的别名,必须是模型的字段名
+	build func(d Dialect) (string, []any, error)
+}
+
+// As 返回以 column 为别名的表达式副本,用于 SelectExpr
+func (e Expr) As(column string) Expr {
+	e.Alias = column
+	return e
+}
+
+// Render 按方言渲染表达式,返回使用 ? 占位符的 SQL 与按顺序排列的参数
+func (e Expr) Render(d Dialect) (string, []any, error) {
+	sqlStr, args := e.SQL, e.Args
+	if e.build != nil {
+		var err error
+		if sqlStr, args, err = e.build(d); err != nil {
+			return "", nil, err
+		}
+	}
+	nested := false
+	for i := 0; i < len(args); i++ {
+		if _, ok := args[i].(Expr); ok {
+			nested = true
+			break
+		}
+	}
+	if !nested {
+		return sqlStr, args, nil
+	}
+
+	// 展开嵌套的表达式
+	buf := utils.NewBuffer(len(sqlStr) + 32)
+	out := make([]any, 0, len(args))
+	n := 0
+	for i := 0; i < len(sqlStr); i++ {
+		if sqlStr[i] != '?' || n >= len(args) {
+			buf.WriteByte(sqlStr[i])
+			continue
+		}
+		sub, ok := args[n].(Expr)
+		if !ok {
+			buf.WriteByte('?')
+			out = append(out, args[n])
+			n++
+			continue
+		}
+		subSQL, subArgs, err := sub.Render(d)
+		if err != nil {
+			return "", nil, err
+		}
+		buf.WriteString(subSQL)
+		out = append(out, subArgs...)
+		n++
+	}
+	return buf.String(), append(out, args[n:]...), nil
+}
+
+// Column 创建引用字段的表达式,字段名可以是 table.column 形式
+func Column(name string) Expr {
+	return Expr{build: func(d Dialect) (string, []any, error) {
+		buf := utils.NewBuffer(len(name) + 4)
+		writeColumn(&buf, d.quote(), name)
+		return buf.String(), nil, nil
+	}}
+}
+
+// Coalesce 创建 COALESCE 表达式
+// column 为字符串时是字段名,为 Expr 时是表达式;values 中的 Expr 渲染为表达式,其他值以占位符绑定,引用字段时使用 Column
+func Coalesce(column any, values ...any) Expr {
+	return Expr{build: func(d Dialect) (string, []any, error) {
+		buf := utils.NewBuffer(11 + len(values)*2)
+		buf.WriteString("COALESCE(?")
+		args := make([]any, 0, len(values)+1)
+		args = append(args, operand(column))
+		for i := 0; i < len(values); i++ {
+			buf.WriteString(",?")
+			args = append(args, values[i])
+		}
+		buf.WriteByte(')')
+		return buf.String(), args, nil
+	}}
+}
+
+// Now 创建当前时间表达式,MySQL 与 PostgreSQL 渲染为 NOW(),SQLite 渲染为 CURRENT_TIMESTAMP
+func Now() Expr {
+	return Expr{build: func(d Dialect) (string, []any, error) {
+		if d == DialectSQLite {
+			return "CURRENT_TIMESTAMP", nil, nil
+		}
+		return "NOW()", nil, nil
+	}}
+}
+
+// 日期截断在 MySQL 与 SQLite 中使用的格式
+var (
+	mysqlTruncFormats = map[string]string{
+		"year":   "%Y-01-01",
+		"month":  "%Y-%m-01",
+		"day":    "%Y-%m-%d",
+		"hour":   "%Y-%m-%d %H:00:00",
+		"minute": "%Y-%m-%d %H:%i:00",
+	}
+	sqliteTruncFormats = map[string]string{
+		"year":   "%Y-01-01 00:00:00",
+		"month":  "%Y-%m-01 00:00:00",
+		"day":    "%Y-%m-%d 00:00:00",
+		"hour":   "%Y-%m-%d %H:00:00",
+		"minute": "%Y-%m-%d %H:%M:00",
+	}
+)
+
+// DateTrunc 创建将时间截断到 unit 的表达式,unit 为 year、month、day、hour 或 minute
+// value 为字符串时是字段名,为 Expr 时是表达式,其他值以占位符绑定
+// PostgreSQL 渲染为 DATE_TRUNC,MySQL 渲染为 DATE_FORMAT 后转换为 DATETIME,SQLite 渲染为 strftime 返回的文本
+func DateTrunc(unit string, value any) Expr {
+	return Expr{build: func(d Dialect) (string, []any, error) {
+		unit := strings.ToLower(unit)
+		format, ok := mysqlTruncFormats[unit]
+		if !ok {
+			return "", nil, errors.New("unknown date truncation unit: " + unit)
+		}
+		args := []any{operand(value)}
+		switch d {
+		case DialectPostgres:
+			return "DATE_TRUNC('" + unit + "',?)", args, nil
+		case DialectSQLite:
+			return "strftime('" + sqliteTruncFormats[unit] + "',?)", args, nil
+		}
+		return "CAST(DATE_FORMAT(?,'" + format + "') AS DATETIME)", args, nil
+	}}
+}
+
+// operand 将辅助函数中表示字段的参数转换为表达式参数,字符串视为字段名
+func operand(v any) any {
+	if name, ok := v.(string); ok {
+		return Column(name)
+	}
+	return v
+}
+
+// Assignment UPDATE 语句 SET 子句中的一项赋值
+type Assignment struct {
+	Column string // 字段名
+	Value  any    // 值,为 Expr 时渲染为表达式
+}
+
+// MapAssignments 将字段名到值的映射转换为赋值,按字段的注册顺序排列,字段名必须已在 elems 中注册
+func MapAssignments(elems []Elem, values map[string]any) ([]Assignment, error) {
+	for column := range values {
+		if IndexElem(elems, column) == -1 {
+			return nil, errors.New("unknown update column: " + column)
+		}
+	}
+	sets := make([]Assignment, 0, len(values))
+	for i := 0; i < len(elems); i++ {
+		if value, ok := values[elems[i].Tag]; ok {
+			sets = append(sets, Assignment{Column: elems[i].Tag, Value: value})
+		}
+	}
+	return sets, nil
+}
+
+// SplitAssignments 从查询条件中分离出 Set 创建的赋值,其余部分按原顺序返回
+func SplitAssignments(queryParts []any) ([]any, []Assignment) {
+	var parts []any
+	var sets []Assignment
+	for i := 0; i < len(queryParts); i++ {
+		if cond, ok := queryParts[i].(Condition); ok && cond.Type == SET {
+			if sets == nil {
+				parts = append(make([]any, 0, len(queryParts)), queryParts[:i]...)
+			}
+			column, _ := cond.Left.(string)
+			sets = append(sets, Assignment{Column: column, Value: cond.Right})
+			continue
+		}
+		if sets != nil {
+			parts = append(parts, queryParts[i])
+		}
+	}
+	if sets == nil {
+		return queryParts, nil
+	}
+	return parts, sets
+}
+
+// WriteAssignments 将 sets 渲染为 SET 子句的赋值列表,使用 ? 占位符,返回按顺序排列的参数
+// 字段名必须已在 elems 中注册
+func WriteAssignments(buf *utils.Buffer, d Dialect, elems []Elem, sets []Assignment) ([]any, error) {
+	args := make([]any, 0, len(sets))
+	for i := 0; i < len(sets); i++ {
+		if IndexElem(elems, sets[i].Column) == -1 {
+			return nil, errors.New("unknown set column: " + sets[i].Column)
+		}
+		writeColumn(buf, d.quote(), sets[i].Column)
+		buf.WriteByte('=')
+		expr, ok := sets[i].Value.(Expr)
+		if !ok {
+			buf.WriteString("?,")
+			args = append(args, sets[i].Value)
+			continue
+		}
+		sqlStr, exprArgs, err := expr.Render(d)
+		if err != nil {
+			return nil, err
+		}
+		buf.WriteString(sqlStr)
+		buf.WriteByte(',')
+		args = append(args, exprArgs...)
+	}
+	buf.TruncateLast(1) // 移除末尾的逗号
+	return args, nil
+}
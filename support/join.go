@@ -0,0 +1,377 @@
+// Copyright 2024 OblivionOcean
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package support
+
+import (
+	"database/sql"
+	"errors"
+	"reflect"
+	"strings"
+	"unsafe"
+
+	"github.com/OblivionOcean/opao/utils"
+)
+
+// JOIN 类型
+const (
+	JoinDefault = "JOIN"
+	JoinLeft    = "LEFT JOIN"
+	JoinInner   = "INNER JOIN"
+)
+
+// Join 查询中的一张表,第一张为主表,其余通过 JOIN 连接
+type Join struct {
+	Kind    string       // JOIN 类型,主表为空
+	Table   string       // 表名
+	Elems   []Elem       // 字段元素列表
+	ObjType reflect.Type // 模型类型
+	On      string       // 渲染后的 ON 条件,主表为空
+}
+
+// NewJoin 解析 tables 与 model 之间的 JOIN,model 必须已在 config 所属的 ORM 中注册
+// on 为空时根据字段的 option:"references=table.column" 标签生成 ON 条件,
+// 先查找 model 中引用已连接表的字段,再查找已连接表中引用 model 的字段;
+// on 为一个元素时原样作为 ON 条件;为两个元素时依次为已连接表与 model 的字段,生成 "a"."col" = "b"."col" 形式的条件,
+// 字段名可以使用 table.column 形式,省略表名时分别为主表与 model;更多元素返回错误
+// 同一张表不能连接两次
+func NewJoin(config *Config, tables []Join, kind string, model any, on []string, quote byte) (Join, error) {
+	cache, err := config.Model(model)
+	if err != nil {
+		return Join{}, err
+	}
+	join := Join{Kind: kind, Table: cache.Table, Elems: cache.Elems, ObjType: cache.ObjType}
+	for i := 0; i < len(tables); i++ {
+		if tables[i].Table == join.Table {
+			return Join{}, errors.New("table already joined: " + join.Table)
+		}
+	}
+	switch len(on) {
+	case 0:
+	case 1:
+		join.On = on[0]
+		return join, nil
+	case 2:
+		all := append(tables[:len(tables):len(tables)], join)
+		left, leftColumn, err := onColumn(all, tables[0], on[0])
+		if err != nil {
+			return Join{}, err
+		}
+		right, rightColumn, err := onColumn(all, join, on[1])
+		if err != nil {
+			return Join{}, err
+		}
+		if IndexElem(left.Elems, leftColumn) == -1 {
+			return Join{}, errors.New("unknown column in JOIN condition: " + left.Table + "." + leftColumn)
+		}
+		join.On, err = joinOn(quote, left, leftColumn, right, rightColumn)
+		return join, err
+	default:
+		return Join{}, errors.New("JOIN accepts an ON condition or a pair of columns")
+	}
+
+	// model 中引用已连接表的字段
+	for i := 0; i < len(join.Elems); i++ {
+		table, column, ok := reference(join.Elems[i])
+		if !ok {
+			continue
+		}
+		for j := 0; j < len(tables); j++ {
+			if tables[j].Table == table {
+				join.On, err = joinOn(quote, join, join.Elems[i].Tag, tables[j], column)
+				return join, err
+			}
+		}
+	}
+	// 已连接表中引用 model 的字段
+	for j := 0; j < len(tables); j++ {
+		for i := 0; i < len(tables[j].Elems); i++ {
+			table, column, ok := reference(tables[j].Elems[i])
+			if ok && table == join.Table {
+				join.On, err = joinOn(quote, tables[j], tables[j].Elems[i].Tag, join, column)
+				return join, err
+			}
+		}
+	}
+	return Join{}, errors.New("no references option between " + join.Table + " and joined tables, specify the ON condition")
+}
+
+// reference 解析字段的 references=table.column 选项
+func reference(elem Elem) (table, column string, ok bool) {
+	ref := elem.Option["references"]
+	i := strings.LastIndexByte(ref, '.')
+	if i <= 0 || i == len(ref)-1 {
+		return "", "", false
+	}
+	return ref[:i], ref[i+1:], true
+}
+
+// onColumn 解析 ON 条件中 table.column 形式的字段名,省略表名时使用 def
+func onColumn(tables []Join, def Join, name string) (Join, string, error) {
+	i := strings.LastIndexByte(name, '.')
+	if i == -1 {
+		return def, name, nil
+	}
+	for j := 0; j < len(tables); j++ {
+		if tables[j].Table == name[:i] {
+			return tables[j], name[i+1:], nil
+		}
+	}
+	return Join{}, "", errors.New("unknown table in JOIN condition: " + name[:i])
+}
+
+// joinOn 生成 "left"."column" = "right"."column" 形式的 ON 条件
+func joinOn(quote byte, left Join, leftColumn string, right Join, rightColumn string) (string, error) {
+	if IndexElem(right.Elems, rightColumn) == -1 {
+		return "", errors.New("unknown referenced column: " + right.Table + "." + rightColumn)
+	}
+	buf := utils.NewBuffer(len(left.Table) + len(leftColumn) + len(right.Table) + len(rightColumn) + 16)
+	writeColumn(&buf, quote, left.Table+"."+leftColumn)
+	buf.WriteString(" = ")
+	writeColumn(&buf, quote, right.Table+"."+rightColumn)
+	return buf.String(), nil
+}
+
+// WriteFrom 写入 FROM 子句,tables 的第一张为主表,其余渲染为 JOIN ... ON ...
+func WriteFrom(buf *utils.Buffer, quote byte, tables []Join) {
+	buf.WriteString(" FROM ")
+	writeColumn(buf, quote, tables[0].Table)
+	for i := 1; i < len(tables); i++ {
+		buf.WriteByte(' ')
+		buf.WriteString(tables[i].Kind)
+		buf.WriteByte(' ')
+		writeColumn(buf, quote, tables[i].Table)
+		buf.WriteString(" ON ")
+		buf.WriteString(tables[i].On)
+	}
+}
+
+// WriteColumns 写入以逗号分隔的字段列表,table 不为空时使用 "table"."column" 限定字段名
+func WriteColumns(buf *utils.Buffer, quote byte, table string, elems []Elem) {
+	for i := 0; i < len(elems); i++ {
+		if table != "" {
+			writeColumn(buf, quote, table)
+			buf.WriteByte('.')
+		}
+		writeColumn(buf, quote, elems[i].Tag)
+		buf.WriteByte(',')
+	}
+	buf.TruncateLast(1) // 移除末尾的逗号
+}
+
+// QualifiedElems 返回用于校验子句字段名的字段列表
+// 包含主表的字段名以及所有表以 table.column 限定的字段名
+func QualifiedElems(tables []Join) []Elem {
+	n := len(tables[0].Elems)
+	for i := 0; i < len(tables); i++ {
+		n += len(tables[i].Elems)
+	}
+	elems := make([]Elem, 0, n)
+	elems = append(elems, tables[0].Elems...)
+	for i := 0; i < len(tables); i++ {
+		for j := 0; j < len(tables[i].Elems); j++ {
+			elem := tables[i].Elems[j]
+			elem.Tag = tables[i].Table + "." + elem.Tag
+			elems = append(elems, elem)
+		}
+	}
+	return elems
+}
+
+// JoinScanner 将 JOIN 查询的结果扫描到组合结构体
+// 组合结构体的字段(包括嵌入字段)为已连接的模型或其指针时,按 "table.column" 别名扫描对应表的字段;
+// 组合结构体本身为已连接的模型时整行扫描到该模型
+type JoinScanner struct {
+	dest    reflect.Value // 目标结构体或切片
+	many    bool          // 目标为切片
+	ptrRow  bool          // 切片元素为指针
+	rowType reflect.Type  // 组合结构体类型
+	targets []joinTarget
+}
+
+// joinTarget 组合结构体中对应一张表的字段
+type joinTarget struct {
+	table  Join
+	offset uintptr // 字段在组合结构体中的偏移量,组合结构体本身为模型时为 0
+	ptr    bool    // 字段为模型指针,整行为 NULL 时保持为 nil
+}
+
+// NewJoinScanner 根据 dest 的类型与 tables 创建扫描器
+// dest 可以是 *T、*[]T 或 *[]*T,T 为组合结构体
+func NewJoinScanner(dest any, tables []Join) (*JoinScanner, error) {
+	val := reflect.ValueOf(dest)
+	if val.Kind() != reflect.Pointer || val.IsNil() {
+		return nil, errors.New("dest must be a non-nil pointer to a struct or a slice of structs")
+	}
+	s := &JoinScanner{dest: val.Elem(), rowType: val.Elem().Type()}
+	if s.rowType.Kind() == reflect.Slice {
+		s.many = true
+		s.rowType = s.rowType.Elem()
+		if s.rowType.Kind() == reflect.Pointer {
+			s.ptrRow = true
+			s.rowType = s.rowType.Elem()
+		}
+	}
+	if s.rowType.Kind() != reflect.Struct {
+		return nil, errors.New("dest must be a non-nil pointer to a struct or a slice of structs")
+	}
+
+	for i := 0; i < len(tables); i++ {
+		if tables[i].ObjType == s.rowType {
+			s.targets = append(s.targets, joinTarget{table: tables[i]})
+		}
+	}
+	for f := 0; f < s.rowType.NumField(); f++ {
+		field := s.rowType.Field(f)
+		fieldType := field.Type
+		ptr := fieldType.Kind() == reflect.Pointer
+		if ptr {
+			fieldType = fieldType.Elem()
+		}
+		for i := 0; i < len(tables); i++ {
+			if tables[i].ObjType == fieldType {
+				s.targets = append(s.targets, joinTarget{table: tables[i], offset: field.Offset, ptr: ptr})
+				break
+			}
+		}
+	}
+	if len(s.targets) == 0 {
+		return nil, errors.New("dest has no fields of the queried models")
+	}
+	return s, nil
+}
+
+// WriteColumns 写入扫描所需的字段列表,形如 "table"."column" AS "table.column"
+func (s *JoinScanner) WriteColumns(buf *utils.Buffer, quote byte) {
+	for i := 0; i < len(s.targets); i++ {
+		table := s.targets[i].table
+		for j := 0; j < len(table.Elems); j++ {
+			writeColumn(buf, quote, table.Table)
+			buf.WriteByte('.')
+			writeColumn(buf, quote, table.Elems[j].Tag)
+			buf.WriteString(" AS ")
+			buf.WriteByte(quote)
+			buf.WriteString(table.Table)
+			buf.WriteByte('.')
+			buf.WriteString(table.Elems[j].Tag)
+			buf.WriteByte(quote)
+			buf.WriteByte(',')
+		}
+	}
+	buf.TruncateLast(1) // 移除末尾的逗号
+}
+
+// Scan 扫描 rows 并写入 dest
+// 结果列按名称(WriteColumns 写入的 "table.column" 别名)对应组合结构体中的字段,不依赖列的顺序
+// dest 为切片时覆盖原有内容;为结构体时只扫描第一行,没有结果时返回 ErrRecordNotFound
+// 字段为 NULL 时保持零值,模型指针字段对应的所有字段均为 NULL 时保持为 nil
+func (s *JoinScanner) Scan(rows *sql.Rows) error {
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	// index[i] 为第 i 列对应的目标与字段下标
+	index := make([][2]int, len(columns))
+	types := make([]reflect.Type, len(columns))
+	for i := 0; i < len(columns); i++ {
+		index[i] = s.column(columns[i])
+		if index[i][0] == -1 {
+			return errors.New("unknown column: " + columns[i])
+		}
+		types[i] = s.targets[index[i][0]].table.Elems[index[i][1]].Type
+	}
+	scans := make([]any, len(columns))
+	values := make([]reflect.Value, len(columns))
+	// fields[t] 按表的字段顺序排列第 t 个目标的扫描结果
+	fields := make([][]reflect.Value, len(s.targets))
+	for i := 0; i < len(s.targets); i++ {
+		fields[i] = make([]reflect.Value, len(s.targets[i].table.Elems))
+	}
+	if s.many {
+		s.dest.SetLen(0)
+	}
+
+	for rows.Next() {
+		if err := scanRow(rows, types, scans, values); err != nil {
+			return err
+		}
+		for i := 0; i < len(columns); i++ {
+			fields[index[i][0]][index[i][1]] = values[i]
+		}
+		row := reflect.New(s.rowType)
+		for i := 0; i < len(s.targets); i++ {
+			s.targets[i].fill(row.UnsafePointer(), fields[i])
+		}
+
+		if !s.many {
+			s.dest.Set(row.Elem())
+			return TranslateError(rows.Err())
+		}
+		if s.ptrRow {
+			s.dest.Set(reflect.Append(s.dest, row))
+		} else {
+			s.dest.Set(reflect.Append(s.dest, row.Elem()))
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return TranslateError(err)
+	}
+	if !s.many {
+		return ErrRecordNotFound
+	}
+	return nil
+}
+
+// column 返回 "table.column" 形式的列名对应的目标与字段下标,不存在时返回 {-1, -1}
+func (s *JoinScanner) column(name string) [2]int {
+	for i := 0; i < len(s.targets); i++ {
+		table := s.targets[i].table
+		if !strings.HasPrefix(name, table.Table) || len(name) <= len(table.Table) || name[len(table.Table)] != '.' {
+			continue
+		}
+		if j := IndexElem(table.Elems, name[len(table.Table)+1:]); j != -1 {
+			return [2]int{i, j}
+		}
+	}
+	return [2]int{-1, -1}
+}
+
+// fill 将一张表的扫描结果写入 row 所指组合结构体中对应的字段
+// values 与表的字段一一对应,为指向字段值的 *T,NULL 或结果中没有的字段为零值 Value
+// 通过偏移量访问字段,组合结构体与模型的字段可以不导出
+func (target joinTarget) fill(row unsafe.Pointer, values []reflect.Value) {
+	base := unsafe.Add(row, target.offset)
+	if target.ptr {
+		null := true
+		for i := 0; i < len(values); i++ {
+			if values[i].IsValid() {
+				null = false
+				break
+			}
+		}
+		if null {
+			return
+		}
+		obj := reflect.New(target.table.ObjType)
+		*(*unsafe.Pointer)(base) = obj.UnsafePointer()
+		base = obj.UnsafePointer()
+	}
+	elems := target.table.Elems
+	for i := 0; i < len(values); i++ {
+		if !values[i].IsValid() {
+			continue
+		}
+		reflect.NewAt(elems[i].Type, unsafe.Add(base, elems[i].Offset)).Elem().Set(values[i].Elem())
+	}
+}
@@ -0,0 +1,65 @@
+// Copyright 2024 OblivionOcean
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package support
+
+import (
+	"database/sql"
+	"errors"
+)
+
+// 行锁模式与等待策略
+const (
+	LockUpdate     = "FOR UPDATE"  // 排他锁
+	LockShare      = "FOR SHARE"   // 共享锁
+	LockNoWait     = "NOWAIT"      // 行已被锁定时立即返回错误
+	LockSkipLocked = "SKIP LOCKED" // 跳过已被锁定的行
+)
+
+// Lock 查询的行锁子句,渲染在 LIMIT 之后
+type Lock struct {
+	Mode string // LockUpdate 或 LockShare,为空时不加锁
+	Wait string // LockNoWait 或 LockSkipLocked,为空时等待锁释放
+}
+
+// With 返回设置了 mode 与 wait 的 Lock,空字符串表示保持原值
+// 行锁只在事务中有意义,conn 不是 *sql.Tx 时返回 ErrLockOutsideTx
+// 只支持 Database.Transaction 开启的事务(或 WithConn 传入的 *sql.Tx);在 *sql.Conn 上手动执行 BEGIN 等方式开启的事务
+// 无法识别,同样返回 ErrLockOutsideTx
+func (l Lock) With(conn Executor, mode, wait string) (Lock, error) {
+	if _, ok := conn.(*sql.Tx); !ok {
+		return l, ErrLockOutsideTx
+	}
+	if mode != "" {
+		l.Mode = mode
+	}
+	if wait != "" {
+		if l.Mode == "" {
+			return l, errors.New(wait + " requires ForUpdate or ForShare")
+		}
+		l.Wait = wait
+	}
+	return l, nil
+}
+
+// String 返回行锁子句,不加锁时返回空字符串
+func (l Lock) String() string {
+	if l.Mode == "" {
+		return ""
+	}
+	if l.Wait == "" {
+		return l.Mode
+	}
+	return l.Mode + " " + l.Wait
+}
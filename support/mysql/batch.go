@@ -0,0 +1,153 @@
+// Copyright 2024 OblivionOcean
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mysql
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"github.com/OblivionOcean/opao/support"
+	"github.com/OblivionOcean/opao/utils"
+)
+
+const (
+	maxParams = 65535 // 预处理语句的占位符上限
+)
+
+// CreateBatch 批量插入记录
+// objs 为已注册结构体或其指针的切片,按 batchSize 拆分为多条 INSERT ... VALUES (...),(...) 语句
+// batchSize <= 0 或超过占位符上限时按上限拆分,同时保证单条语句不超过 max_allowed_packet
+// 自增字段按 LastInsertId 与 auto_increment_increment 回写到每个元素,
+// 依赖同一条 INSERT 分配连续的自增值(innodb_autoinc_lock_mode 为 0 或 1 时成立)
+// 多条语句之间不保证原子性,需要时请在事务中调用
+// 参数:
+//   - objs: 待插入的对象列表
+//   - batchSize: 每条语句插入的最大行数
+//
+// 返回:
+//   - error: 执行错误
+func (qt *MySQL) CreateBatch(objs any, batchSize int) error {
+	return qt.CreateBatchContext(context.Background(), objs, batchSize)
+}
+
+// CreateBatchContext 与 CreateBatch 相同,使用 ctx 控制超时与取消
+func (qt *MySQL) CreateBatchContext(ctx context.Context, objs any, batchSize int) error {
+	ctx, cancel := qt.config.Context(ctx)
+	defer cancel()
+
+	if qt.err != nil {
+		return qt.err
+	}
+	rows, err := support.BatchRows(objs, qt.objType)
+	if err != nil || len(rows) == 0 {
+		return err
+	}
+
+	// 区分插入字段与自增字段
+	cols := make([]support.Elem, 0, len(qt.Elems))
+	autoInc := -1
+	for i := 0; i < len(qt.Elems); i++ {
+		if qt.Elems[i].Option["autoIncrement"] == "-" {
+			autoInc = i
+			continue
+		}
+		cols = append(cols, qt.Elems[i])
+	}
+	if len(cols) == 0 {
+		return errors.New("no columns to insert")
+	}
+
+	// 计算每条语句的行数上限
+	maxRows := maxParams / len(cols)
+	if batchSize <= 0 || batchSize > maxRows {
+		batchSize = maxRows
+	}
+	maxPacket := 0
+	if packet, err := qt.config.ServerInfo(ctx, qt.conn, "SELECT @@max_allowed_packet"); err == nil {
+		maxPacket, _ = strconv.Atoi(packet)
+		maxPacket -= 1024 // 预留协议头部空间
+	}
+	step := int64(1) // 相邻两行自增值的差
+	if autoInc != -1 {
+		if inc, err := qt.config.ServerInfo(ctx, qt.conn, "SELECT @@auto_increment_increment"); err == nil {
+			if n, err := strconv.ParseInt(inc, 10, 64); err == nil && n > 0 {
+				step = n
+			}
+		}
+	}
+
+	// 构建 INSERT 语句头部
+	header := utils.NewBuffer(22 + len(qt.Table) + len(cols)*8)
+	header.WriteString("INSERT INTO `")
+	header.WriteString(qt.Table)
+	header.WriteString("` (")
+	for i := 0; i < len(cols); i++ {
+		header.WriteByte('`')
+		header.WriteString(cols[i].Tag)
+		header.WriteString("`,")
+	}
+	header.TruncateLast(1) // 移除末尾的逗号
+	header.WriteString(") VALUES ")
+
+	values := make([]any, 0, batchSize*len(cols))
+	for start := 0; start < len(rows); {
+		buf := utils.NewBuffer(len(header) + batchSize*(len(cols)*2+2))
+		buf.Write(header...)
+		values = values[:0]
+		size := len(header)
+		end := start
+		for end < len(rows) && end-start < batchSize {
+			mark := len(values)
+			rowSize := 3 + len(cols)*2
+			for i := 0; i < len(cols); i++ {
+				elem := cols[i].At(rows[end])
+				val := elem.Get()
+				values = append(values, val)
+				rowSize += support.ValueSize(val)
+			}
+			// 保证至少插入一行,其余行受 max_allowed_packet 限制
+			if end > start && maxPacket > 0 && size+rowSize > maxPacket {
+				values = values[:mark]
+				break
+			}
+			size += rowSize
+			buf.WriteByte('(')
+			for i := 0; i < len(cols); i++ {
+				buf.WriteString("?,")
+			}
+			buf.TruncateLast(1) // 移除末尾的逗号
+			buf.WriteString("),")
+			end++
+		}
+		buf.TruncateLast(1) // 移除末尾的逗号
+
+		// 执行 INSERT 语句并按 LastInsertId 回写自增字段
+		r, err := qt.conn.ExecContext(ctx, buf.String(), values...)
+		if err != nil {
+			return support.TranslateError(err)
+		}
+		if autoInc != -1 {
+			if lii, err := r.LastInsertId(); err == nil {
+				for i := start; i < end; i++ {
+					elem := qt.Elems[autoInc].At(rows[i])
+					_ = elem.SetInt64(lii + int64(i-start)*step)
+				}
+			}
+		}
+		start = end
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+// Copyright 2024 OblivionOcean
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mysql
+
+import "github.com/OblivionOcean/opao/support"
+
+// With 返回在语句前添加公用表表达式的 ObjectORM 副本
+// name 为表达式名称,可以带字段列表,如 tree(id,parent_id);subquery 可以是 SQL 字符串、带参数的 Custom 条件或 Subquery
+// 公用表表达式作用于查询、计数、聚合、Update 与 Delete,条件与子查询中可以像表一样引用它,参数排在语句其余参数之前
+// 解析失败时通过 Error 返回错误
+func (qt *MySQL) With(name string, subquery any) support.ObjectORM {
+	return qt.with(name, subquery, false)
+}
+
+// WithRecursive 与 With 相同,但渲染为 WITH RECURSIVE,subquery 中可以引用 name 自身
+func (qt *MySQL) WithRecursive(name string, subquery any) support.ObjectORM {
+	return qt.with(name, subquery, true)
+}
+
+// From 返回从 name 而不是模型表读取数据的 ObjectORM 副本,通常是 With 定义的公用表表达式
+// name 的字段需要与模型字段一致;与 Join 一起使用时需要先调用 From;写操作不支持 From
+func (qt *MySQL) From(name string) support.ObjectORM {
+	cp := *qt
+	cp.from = name
+	return &cp
+}
+
+// with 返回追加了一项公用表表达式的副本
+func (qt *MySQL) with(name string, subquery any, recursive bool) support.ObjectORM {
+	cp := *qt
+	if qt.err != nil {
+		return &cp
+	}
+	cte, err := support.NewCTE(name, subquery, recursive, qt.config, qt.subquery)
+	if err != nil {
+		cp.err = err
+		return &cp
+	}
+	cp.ctes = append(qt.ctes[:len(qt.ctes):len(qt.ctes)], cte)
+	return &cp
+}
+
+// source 返回查询读取的表,设置 From 时为其名称,否则为模型表
+func (qt *MySQL) source() string {
+	if qt.from != "" {
+		return qt.from
+	}
+	return qt.Table
+}
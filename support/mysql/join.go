@@ -0,0 +1,135 @@
+// Copyright 2024 OblivionOcean
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mysql
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/OblivionOcean/opao/support"
+	"github.com/OblivionOcean/opao/utils"
+)
+
+// Join 返回与 model 进行 JOIN 的 ObjectORM 副本
+// model 必须已注册;on 为空时根据 option:"references=table.column" 标签生成 ON 条件,
+// 为一个元素时原样作为 ON 条件,为两个元素时作为已连接表与 model 的字段对生成等值条件,参见 support.NewJoin
+// 连接后查询的字段以表名限定,条件与子句中可以使用 table.column 形式的字段名;写操作不支持 JOIN
+// 解析失败时通过 Error 返回错误
+func (qt *MySQL) Join(model any, on ...string) support.ObjectORM {
+	return qt.join(support.JoinDefault, model, on)
+}
+
+// LeftJoin 返回与 model 进行 LEFT JOIN 的 ObjectORM 副本,参见 Join
+func (qt *MySQL) LeftJoin(model any, on ...string) support.ObjectORM {
+	return qt.join(support.JoinLeft, model, on)
+}
+
+// InnerJoin 返回与 model 进行 INNER JOIN 的 ObjectORM 副本,参见 Join
+func (qt *MySQL) InnerJoin(model any, on ...string) support.ObjectORM {
+	return qt.join(support.JoinInner, model, on)
+}
+
+// join 返回追加了一张连接表的副本
+func (qt *MySQL) join(kind string, model any, on []string) support.ObjectORM {
+	cp := *qt
+	if qt.err != nil {
+		return &cp
+	}
+	join, err := support.NewJoin(qt.config, qt.tables(), kind, model, on, '`')
+	if err != nil {
+		cp.err = err
+		return &cp
+	}
+	cp.joins = append(qt.joins[:len(qt.joins):len(qt.joins)], join)
+	return &cp
+}
+
+// tables 返回主表与所有连接表
+func (qt *MySQL) tables() []support.Join {
+	tables := make([]support.Join, 0, len(qt.joins)+1)
+	tables = append(tables, support.Join{Table: qt.source(), Elems: qt.Elems, ObjType: qt.objType})
+	return append(tables, qt.joins...)
+}
+
+// clauseElems 返回子句中可用的字段,连接其他表时包含 table.column 形式的限定字段名
+func (qt *MySQL) clauseElems() []support.Elem {
+	if len(qt.joins) == 0 {
+		return qt.Elems
+	}
+	return support.QualifiedElems(qt.tables())
+}
+
+// Scan 查询并将结果扫描到组合结构体 dest
+// dest 可以是 *T、*[]T 或 *[]*T,T 的字段(包括嵌入字段)为查询涉及的模型或其指针,
+// 各表字段以 "table.column" 为别名查询并写入对应字段;模型指针字段在 LEFT JOIN 未匹配时保持为 nil
+// dest 为 *T 时只读取第一行,没有结果时返回 support.ErrRecordNotFound
+// 参数:
+//   - dest: 组合结构体或其切片的指针
+//   - queryParts: 查询条件部分,可以是条件字符串和参数
+//
+// 返回:
+//   - error: 执行错误
+func (qt *MySQL) Scan(dest any, queryParts ...any) error {
+	return qt.ScanContext(context.Background(), dest, queryParts...)
+}
+
+// ScanContext 与 Scan 相同,使用 ctx 控制超时与取消
+func (qt *MySQL) ScanContext(ctx context.Context, dest any, queryParts ...any) error {
+	ctx, cancel := qt.config.Context(ctx)
+	defer cancel()
+
+	if qt.err != nil {
+		return qt.err
+	}
+	tables := qt.tables()
+	tables[0].Elems = qt.columns() // 主表只查询 Select/Omit 限定的字段
+	scanner, err := support.NewJoinScanner(dest, tables)
+	if err != nil {
+		return err
+	}
+	q, err := qt.buildQuery(queryParts...)
+	if err != nil {
+		return err
+	}
+	if err = qt.checkSets(ctx, &q); err != nil {
+		return err
+	}
+
+	// 构建 SELECT 语句
+	buf := utils.NewBuffer(128 + q.Len())
+	buf.WriteString(q.With)
+	start := len(buf)
+	if qt.distinct {
+		buf.WriteString("SELECT DISTINCT ")
+	} else {
+		buf.WriteString("SELECT ")
+	}
+	scanner.WriteColumns(&buf, '`')
+	support.WriteFrom(&buf, '`', tables)
+	q.WriteCompound(&buf, string(buf[start:]))
+	if lock := qt.lock.String(); lock != "" {
+		buf.WriteByte(' ')
+		buf.WriteString(lock)
+	}
+
+	rows, err := qt.conn.QueryContext(ctx, buf.String(), q.Args()...)
+	if err != nil {
+		return support.TranslateError(err)
+	}
+	defer func(rows *sql.Rows) {
+		_ = rows.Close()
+	}(rows)
+	return support.TranslateError(scanner.Scan(rows))
+}
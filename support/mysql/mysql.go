@@ -15,8 +15,10 @@
 package mysql
 
 import (
+	"context"
 	"database/sql"
 	"database/sql/driver"
+	"errors"
 	"reflect"
 
 	"github.com/OblivionOcean/opao/support"
@@ -25,27 +27,41 @@ import (
 
 // MySQL MySQL 数据库 ORM 实现
 type MySQL struct {
-	Table   string         // 表名
-	err     error          // 错误信息
-	Elems   []support.Elem // 字段元素列表
-	conn    *sql.DB        // 数据库连接
-	obj     any            // 关联的对象
-	objType reflect.Type   // 对象类型
+	Table    string                 // 表名
+	err      error                  // 错误信息
+	Elems    []support.Elem         // 字段元素列表
+	conn     support.Executor       // 数据库连接或事务
+	config   *support.Config        // ORM 配置
+	obj      any                    // 关联的对象
+	objType  reflect.Type           // 对象类型
+	reuse    bool                   // Each 遍历时复用行对象
+	conflict support.Conflict       // Upsert 冲突处理方式
+	global   bool                   // 允许没有条件的全表写入
+	fields   []support.Elem         // Select/Omit 限定的字段,为 nil 时使用全部字段
+	selected bool                   // 字段由 Select 显式指定,Update/Create 时写入零值
+	distinct bool                   // 查询时使用 SELECT DISTINCT
+	joins    []support.Join         // JOIN 连接的表
+	ctes     []support.CTE          // With/WithRecursive 定义的公用表表达式
+	sets     []support.SetOperation // UNION 等集合运算追加的 SELECT
+	from     string                 // From 指定的查询来源,为空时使用表名
+	exprs    []support.Expr         // SelectExpr 指定的字段表达式,已按方言渲染
+	lock     support.Lock           // 查询的行锁子句
 }
 
 // NewMySQL 创建 MySQL ORM 实例
 // 参数:
 //   - conn: 数据库连接
+//   - config: ORM 配置
 //   - obj: 关联的对象
 //   - objType: 对象的类型信息
 //   - table: 表名
 //   - Elems: 字段元素列表
 //   - err: 初始化错误
-func NewMySQL(conn *sql.DB, obj any, objType reflect.Type, table string, Elems []support.Elem, err error) support.ObjectORM {
+func NewMySQL(conn support.Executor, config *support.Config, obj any, objType reflect.Type, table string, Elems []support.Elem, err error) support.ObjectORM {
 	if err != nil {
 		return &MySQL{err: err}
 	}
-	return &MySQL{Table: table, Elems: Elems, err: err, conn: conn, obj: obj, objType: objType}
+	return &MySQL{Table: table, Elems: Elems, err: err, conn: conn, config: config, obj: obj, objType: objType}
 }
 
 // Error 返回当前 ORM 实例的错误信息
@@ -53,6 +69,14 @@ func (qt *MySQL) Error() error {
 	return qt.err
 }
 
+// AllowGlobal 返回允许全表写入的 ObjectORM 副本
+// 默认情况下没有条件(且无法按主键匹配)的 Update/Save/Delete 返回 support.ErrMissingWhere
+func (qt *MySQL) AllowGlobal() support.ObjectORM {
+	cp := *qt
+	cp.global = true
+	return &cp
+}
+
 // Update 更新数据库记录
 // 根据提供的查询条件和值更新记录,仅更新非零值且非自增字段的字段
 // 使用 MySQL 的 `table` 引用表名和 ? 占位符
@@ -62,71 +86,46 @@ func (qt *MySQL) Error() error {
 // 返回:
 //   - error: 执行错误
 func (qt *MySQL) Update(queryParts ...any) error {
-	query, args := qt.buildQuery(queryParts...)
+	return qt.UpdateContext(context.Background(), queryParts...)
+}
 
-	// 计算 SQL 语句所需的缓冲区大小
-	elemsLeng := len(qt.Elems)
-	tabNameLen := len(qt.Table)
-	queryStringLen := len(query)
-	elemsNameLength := 0
-	for i := 0; i < elemsLeng; i++ {
-		if qt.Elems[i].Zero() {
-			continue
-		}
-		if qt.Elems[i].Option["autoIncrement"] == "-" {
-			continue
-		}
-		// 字段名(2个反引号) + `=?`(3) + 逗号(1)
-		elemsNameLength += len(qt.Elems[i].Tag) + 4 + 1
-	}
+// UpdateContext 与 Update 相同,使用 ctx 控制超时与取消
+func (qt *MySQL) UpdateContext(ctx context.Context, queryParts ...any) error {
+	_, err := qt.UpdateWithResultContext(ctx, queryParts...)
+	return err
+}
 
-	// 如果没有字段需要更新,直接返回
-	if elemsNameLength == 0 {
-		return nil
-	}
+// UpdateWithResult 与 Update 相同,同时返回受影响的行数与执行的 SQL
+func (qt *MySQL) UpdateWithResult(queryParts ...any) (support.Result, error) {
+	return qt.UpdateWithResultContext(context.Background(), queryParts...)
+}
 
-	// 创建缓冲区并构建 UPDATE 语句
-	var buf utils.Buffer
-	if query == "-" {
-		buf = utils.NewBuffer(15 + tabNameLen + elemsNameLength) // UPDATE `table` SET
-	} else {
-		buf = utils.NewBuffer(21 + tabNameLen + queryStringLen + elemsNameLength) // UPDATE `table` SET WHERE
-	}
-	buf.WriteString("UPDATE `")
-	buf.WriteString(qt.Table)
-	buf.WriteString("` SET ")
+// UpdateWithResultContext 与 UpdateWithResult 相同,使用 ctx 控制超时与取消
+func (qt *MySQL) UpdateWithResultContext(ctx context.Context, queryParts ...any) (support.Result, error) {
+	ctx, cancel := qt.config.Context(ctx)
+	defer cancel()
 
-	// 收集需要更新的字段值并构建 SET 子句
-	values := make([]any, 0, elemsLeng+len(args))
-	for i := 0; i < elemsLeng; i++ {
-		if qt.Elems[i].Zero() {
-			continue
-		}
-		if qt.Elems[i].Option["autoIncrement"] == "-" {
-			continue
-		}
-		buf.WriteByte('`')
-		buf.WriteString(qt.Elems[i].Tag)
-		buf.WriteString("`=?")
-		buf.WriteByte(',')
-		values = append(values, qt.Elems[i].Get())
+	queryParts, sets := support.SplitAssignments(queryParts)
+	query, args, err := qt.writeQuery(queryParts...)
+	if err != nil {
+		return support.Result{}, err
+	}
+	sqlStr, values, err := qt.buildUpdate(query, args, sets, false)
+	if err != nil {
+		return support.Result{}, err
 	}
-	buf.TruncateLast(1) // 移除末尾的逗号
 
-	// 构建 WHERE 子句
-	if query != "" {
-		buf.WriteString(" WHERE ")
-		buf.WriteString(query)
-		values = append(values, args...)
+	// 如果没有字段需要更新,直接返回
+	if sqlStr == "" {
+		return support.Result{}, nil
 	}
 
 	// 执行 UPDATE 语句
-	r, err := qt.conn.Exec(buf.String(), values...)
+	r, err := qt.conn.ExecContext(ctx, sqlStr, values...)
 	if err != nil {
-		return err
+		return support.Result{}, support.TranslateError(err)
 	}
-	support.WriteLii(qt.Elems, r)
-	return err
+	return support.NewResult(r, sqlStr), nil
 }
 
 // Save 保存或更新数据库记录
@@ -137,60 +136,44 @@ func (qt *MySQL) Update(queryParts ...any) error {
 // 返回:
 //   - error: 执行错误
 func (qt *MySQL) Save(queryParts ...any) error {
-	query, args := qt.buildQuery(queryParts...)
+	return qt.SaveContext(context.Background(), queryParts...)
+}
 
-	// 计算 SQL 语句所需的缓冲区大小
-	elemsLeng := len(qt.Elems)
-	tabNameLen := len(qt.Table)
-	queryStringLen := len(query)
-	elemsNameLength := 0
-	for i := 0; i < elemsLeng; i++ {
-		if qt.Elems[i].Option["autoIncrement"] == "-" {
-			continue
-		}
-		// 字段名(2个反引号) + `=?`(3) + 逗号(1)
-		elemsNameLength += len(qt.Elems[i].Tag) + 4 + 1
-	}
+// SaveContext 与 Save 相同,使用 ctx 控制超时与取消
+func (qt *MySQL) SaveContext(ctx context.Context, queryParts ...any) error {
+	_, err := qt.SaveWithResultContext(ctx, queryParts...)
+	return err
+}
 
-	// 创建缓冲区并构建 UPDATE 语句
-	var buf utils.Buffer
-	if query == "-" {
-		buf = utils.NewBuffer(15 + tabNameLen + elemsNameLength)
-	} else {
-		buf = utils.NewBuffer(21 + tabNameLen + queryStringLen + elemsNameLength)
-	}
-	buf.WriteString("UPDATE `")
-	buf.WriteString(qt.Table)
-	buf.WriteString("` SET ")
+// SaveWithResult 与 Save 相同,同时返回受影响的行数与执行的 SQL
+func (qt *MySQL) SaveWithResult(queryParts ...any) (support.Result, error) {
+	return qt.SaveWithResultContext(context.Background(), queryParts...)
+}
 
-	// 收集所有字段值并构建 SET 子句
-	values := make([]any, 0, elemsLeng+len(args))
-	for i := 0; i < elemsLeng; i++ {
-		if qt.Elems[i].Option["autoIncrement"] == "-" {
-			continue
-		}
-		buf.WriteByte('`')
-		buf.WriteString(qt.Elems[i].Tag)
-		buf.WriteString("`=?")
-		buf.WriteByte(',')
-		values = append(values, qt.Elems[i].Get())
-	}
-	buf.TruncateLast(1) // 移除末尾的逗号
+// SaveWithResultContext 与 SaveWithResult 相同,使用 ctx 控制超时与取消
+func (qt *MySQL) SaveWithResultContext(ctx context.Context, queryParts ...any) (support.Result, error) {
+	ctx, cancel := qt.config.Context(ctx)
+	defer cancel()
 
-	// 构建 WHERE 子句
-	if query != "" {
-		buf.WriteString(" WHERE ")
-		buf.WriteString(query)
-		values = append(values, args...)
+	queryParts, sets := support.SplitAssignments(queryParts)
+	query, args, err := qt.writeQuery(queryParts...)
+	if err != nil {
+		return support.Result{}, err
+	}
+	sqlStr, values, err := qt.buildUpdate(query, args, sets, true)
+	if err != nil {
+		return support.Result{}, err
+	}
+	if sqlStr == "" {
+		return support.Result{}, nil
 	}
 
 	// 执行 UPDATE 语句
-	r, err := qt.conn.Exec(buf.String(), values...)
+	r, err := qt.conn.ExecContext(ctx, sqlStr, values...)
 	if err != nil {
-		return err
+		return support.Result{}, support.TranslateError(err)
 	}
-	support.WriteLii(qt.Elems, r)
-	return err
+	return support.NewResult(r, sqlStr), nil
 }
 
 // Delete 删除数据库记录
@@ -202,87 +185,83 @@ func (qt *MySQL) Save(queryParts ...any) error {
 // 返回:
 //   - error: 执行错误
 func (qt *MySQL) Delete(queryParts ...any) error {
-	query, args := qt.buildQuery(queryParts...)
+	return qt.DeleteContext(context.Background(), queryParts...)
+}
 
-	// 计算 SQL 语句所需的缓冲区大小
-	tabNameLen := len(qt.Table)
-	queryStringLen := len(query)
-	var buf utils.Buffer
-	if query == "" {
-		buf = utils.NewBuffer(14 + tabNameLen) // DELETE FROM `table`
-	} else {
-		buf = utils.NewBuffer(20 + tabNameLen + queryStringLen) // DELETE FROM `table` WHERE
-	}
-	buf.WriteString("DELETE FROM `")
-	buf.WriteString(qt.Table)
-	buf.WriteByte('`')
+// DeleteContext 与 Delete 相同,使用 ctx 控制超时与取消
+func (qt *MySQL) DeleteContext(ctx context.Context, queryParts ...any) error {
+	_, err := qt.DeleteWithResultContext(ctx, queryParts...)
+	return err
+}
 
-	// 构建 WHERE 子句
-	if query != "" {
-		buf.WriteString(" WHERE ")
-		buf.WriteString(query)
+// DeleteWithResult 与 Delete 相同,同时返回受影响的行数与执行的 SQL
+func (qt *MySQL) DeleteWithResult(queryParts ...any) (support.Result, error) {
+	return qt.DeleteWithResultContext(context.Background(), queryParts...)
+}
+
+// DeleteWithResultContext 与 DeleteWithResult 相同,使用 ctx 控制超时与取消
+func (qt *MySQL) DeleteWithResultContext(ctx context.Context, queryParts ...any) (support.Result, error) {
+	ctx, cancel := qt.config.Context(ctx)
+	defer cancel()
+
+	query, args, err := qt.writeQuery(queryParts...)
+	if err != nil {
+		return support.Result{}, err
 	}
 
 	// 执行 DELETE 语句
-	_, err := qt.conn.Exec(buf.String(), args...)
-	return err
+	sqlStr, args := qt.buildDelete(query, args)
+	r, err := qt.conn.ExecContext(ctx, sqlStr, args...)
+	if err != nil {
+		return support.Result{}, support.TranslateError(err)
+	}
+	return support.NewResult(r, sqlStr), nil
 }
 
 // Create 插入新记录到数据库
-// 使用 INSERT 语句将数据插入到表中,跳过自增字段
+// 使用 INSERT 语句将数据插入到表中,跳过自增字段与值为零的默认值字段
 // 使用 MySQL 的 `table` 引用表名和 ? 占位符
+// MySQL 不支持 RETURNING,自增字段通过 LastInsertId 回写;
+// 存在由数据库生成默认值的字段时,插入后按主键重新查询这些字段
 // 返回:
 //   - error: 执行错误
 func (qt *MySQL) Create() error {
-	elemsLeng := len(qt.Elems)
-	tabNameLen := len(qt.Table)
+	return qt.CreateContext(context.Background())
+}
 
-	// 计算 SQL 语句所需的缓冲区大小
-	elemsNameLength := 0
-	for i := 0; i < elemsLeng; i++ {
-		if qt.Elems[i].Option["autoIncrement"] == "-" {
-			continue
-		}
-		// 字段名(2个反引号) + 逗号(2)
-		elemsNameLength += len(qt.Elems[i].Tag) + 3 + 2
-	}
+// CreateContext 与 Create 相同,使用 ctx 控制超时与取消
+func (qt *MySQL) CreateContext(ctx context.Context) error {
+	_, err := qt.CreateWithResultContext(ctx)
+	return err
+}
 
-	// 创建缓冲区并构建 INSERT 语句
-	buf := utils.NewBuffer(29 + tabNameLen + elemsNameLength) // INSERT INTO `table` (...) VALUES (...);
-	buf.WriteString("INSERT INTO `")
-	buf.WriteString(qt.Table)
-	buf.WriteString("` (")
+// CreateWithResult 与 Create 相同,同时返回受影响的行数、自增 ID 与执行的 SQL
+func (qt *MySQL) CreateWithResult() (support.Result, error) {
+	return qt.CreateWithResultContext(context.Background())
+}
 
-	// 构建字段列表
-	values := make([]any, 0, elemsLeng)
-	for i := 0; i < elemsLeng; i++ {
-		if qt.Elems[i].Option["autoIncrement"] == "-" {
-			continue
-		}
-		buf.WriteByte('`')
-		buf.WriteString(qt.Elems[i].Tag)
-		buf.WriteByte('`')
-		values = append(values, qt.Elems[i].Get())
-		buf.WriteByte(',')
-	}
-	buf.TruncateLast(1) // 移除末尾的逗号
-	buf.WriteString(") VALUES (")
+// CreateWithResultContext 与 CreateWithResult 相同,使用 ctx 控制超时与取消
+func (qt *MySQL) CreateWithResultContext(ctx context.Context) (support.Result, error) {
+	ctx, cancel := qt.config.Context(ctx)
+	defer cancel()
 
-	// 构建 VALUES 子句,使用 MySQL 的 ? 占位符
-	for i := 0; i < elemsLeng; i++ {
-		buf.WriteByte('?')
-		buf.WriteByte(',')
+	if qt.err != nil {
+		return support.Result{}, qt.err
 	}
-	buf.TruncateLast(1) // 移除末尾的逗号
-	buf.WriteString(");")
+
+	inserts, generated := support.InsertColumns(qt.Elems, qt.columns(), qt.selected)
+	sqlStr, values := qt.buildInsert(inserts)
 
 	// 执行 INSERT 语句
-	r, err := qt.conn.Exec(buf.String(), values...)
+	r, err := qt.conn.ExecContext(ctx, sqlStr, values...)
 	if err != nil {
-		return err
+		return support.Result{}, support.TranslateError(err)
 	}
 	support.WriteLii(qt.Elems, r)
-	return err
+	if err = qt.refresh(ctx, generated); err != nil {
+		return support.Result{}, err
+	}
+	return support.NewResult(r, sqlStr), nil
 }
 
 // FindAll 查询多条记录
@@ -295,40 +274,118 @@ func (qt *MySQL) Create() error {
 //   - []any: 查询结果对象列表
 //   - error: 执行错误
 func (qt *MySQL) FindAll(queryParts ...any) ([]any, error) {
-	query, args := qt.buildQuery(queryParts...)
+	return qt.FindAllContext(context.Background(), queryParts...)
+}
 
-	// 执行查询
-	rows, err := qt.conn.Query(qt.getSelectSQL(query), args...)
-	elemsLen := len(qt.Elems)
+// FindAllContext 与 FindAll 相同,使用 ctx 控制超时与取消
+func (qt *MySQL) FindAllContext(ctx context.Context, queryParts ...any) ([]any, error) {
+	ctx, cancel := qt.config.Context(ctx)
+	defer cancel()
+
+	if qt.err != nil {
+		return nil, qt.err
+	}
+
+	q, err := qt.buildQuery(queryParts...)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, nil
-		}
 		return nil, err
 	}
+	return qt.findAll(ctx, &q)
+}
+
+// findAll 查询匹配 q 的所有记录
+func (qt *MySQL) findAll(ctx context.Context, q *support.Query) ([]any, error) {
+	if err := qt.checkSets(ctx, q); err != nil {
+		return nil, err
+	}
+	// 执行查询
+	rows, err := qt.conn.QueryContext(ctx, qt.getSelectSQL(q), q.Args()...)
+	if err != nil {
+		return nil, support.TranslateError(err)
+	}
 	defer func(rows *sql.Rows) {
 		_ = rows.Close()
 	}(rows)
 
 	// 遍历查询结果
-	var objs []any
-	for rows.Next() {
-		obj := reflect.New(qt.objType).Elem()
-		Scans := make([]any, elemsLen)
+	return qt.scanAll(rows)
+}
+
+// Reuse 返回复用行对象的 ObjectORM 副本
+// Each 遍历时所有行都扫描到加载的对象中,而不是为每行分配新对象
+func (qt *MySQL) Reuse() support.ObjectORM {
+	cp := *qt
+	cp.reuse = true
+	return &cp
+}
+
+// Each 逐行遍历查询结果
+// 每扫描一行调用一次 fn,参数为指向行对象的指针;fn 返回错误时停止遍历并返回该错误
+// 结果集不会整体载入内存,遍历结束或中止时关闭 rows
+// SetTimeout 设置的默认超时只限制查询执行到返回结果集,不限制之后的遍历;需要限制整个遍历时使用 EachContext 传入带截止时间的 ctx
+// 参数:
+//   - fn: 行回调
+//   - queryParts: 查询条件部分,可以是条件字符串和参数
+//
+// 返回:
+//   - error: 执行错误或 fn 返回的错误
+func (qt *MySQL) Each(fn func(obj any) error, queryParts ...any) error {
+	return qt.EachContext(context.Background(), fn, queryParts...)
+}
+
+// EachContext 与 Each 相同,使用 ctx 控制超时与取消
+func (qt *MySQL) EachContext(ctx context.Context, fn func(obj any) error, queryParts ...any) error {
+	ctx, started, cancel := qt.config.StreamContext(ctx)
+	defer cancel()
+
+	if qt.err != nil {
+		return qt.err
+	}
+
+	elems := qt.columns()
+	q, err := qt.buildQuery(queryParts...)
+	if err != nil {
+		return err
+	}
+	if err = qt.checkSets(ctx, &q); err != nil {
+		return err
+	}
+
+	// 执行查询
+	rows, err := qt.conn.QueryContext(ctx, qt.getSelectSQL(&q), q.Args()...)
+	if err != nil {
+		return support.TranslateError(err)
+	}
+	started()
+	defer func(rows *sql.Rows) {
+		_ = rows.Close()
+	}(rows)
+
+	// 复用模式下扫描目标固定为加载对象的字段
+	elemsLen := len(elems)
+	Scans := make([]any, elemsLen)
+	if qt.reuse {
 		for i := 0; i < elemsLen; i++ {
-			// 使用反射获取字段的地址用于扫描
-			Scans[i] = reflect.NewAt(qt.Elems[i].Type, obj.Field(qt.Elems[i].Index).Addr().UnsafePointer()).Interface()
+			Scans[i] = elems[i].GetInterface()
 		}
-		err := rows.Scan(Scans...)
-		if err != nil {
-			if err == sql.ErrNoRows {
-				return nil, nil
-			}
-			return nil, err
+	}
+
+	// 遍历查询结果
+	for rows.Next() {
+		obj := qt.obj
+		if !qt.reuse {
+			objPtr := reflect.New(qt.objType)
+			support.BindScans(Scans, elems, objPtr.UnsafePointer())
+			obj = objPtr.Interface()
+		}
+		if err := rows.Scan(Scans...); err != nil {
+			return support.TranslateError(err)
+		}
+		if err := fn(obj); err != nil {
+			return err
 		}
-		objs = append(objs, obj.Interface())
 	}
-	return objs, nil
+	return support.TranslateError(rows.Err())
 }
 
 // Find 查询单条记录
@@ -339,31 +396,100 @@ func (qt *MySQL) FindAll(queryParts ...any) ([]any, error) {
 //
 // 返回:
 //   - any: 查询结果对象
-//   - error: 执行错误
+//   - error: 执行错误,没有匹配的记录时为 support.ErrRecordNotFound
 func (qt *MySQL) Find(queryParts ...any) (any, error) {
-	query, args := qt.buildQuery(queryParts...)
+	return qt.FindContext(context.Background(), queryParts...)
+}
+
+// FindContext 与 Find 相同,使用 ctx 控制超时与取消
+func (qt *MySQL) FindContext(ctx context.Context, queryParts ...any) (any, error) {
+	ctx, cancel := qt.config.Context(ctx)
+	defer cancel()
+
+	if qt.err != nil {
+		return nil, qt.err
+	}
+
+	elems := qt.columns()
+	q, err := qt.buildQuery(queryParts...)
+	if err != nil {
+		return nil, err
+	}
+	if err = qt.checkSets(ctx, &q); err != nil {
+		return nil, err
+	}
 
 	// 执行查询
-	row := qt.conn.QueryRow(qt.getSelectSQL(query), args...)
+	row := qt.conn.QueryRowContext(ctx, qt.getSelectSQL(&q), q.Args()...)
 
-	elemsLen := len(qt.Elems)
+	elemsLen := len(elems)
 	Scans := make([]any, elemsLen)
 	for i := 0; i < elemsLen; i++ {
-		Scans[i] = qt.Elems[i].GetInterface()
+		Scans[i] = elems[i].GetInterface()
 	}
 
 	// 扫描结果
-	err := row.Scan(Scans...)
+	err = row.Scan(Scans...)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, nil
+			return nil, support.ErrRecordNotFound
 		}
-		return nil, err
+		return nil, support.TranslateError(err)
 	}
 
 	return qt.obj, nil
 }
 
+// FindByPK 按主键查询单条记录
+// ids 依次对应主键字段,复合主键需按声明顺序传入所有字段的值
+// 参数:
+//   - ids: 主键值
+//
+// 返回:
+//   - any: 查询结果对象
+//   - error: 执行错误
+func (qt *MySQL) FindByPK(ids ...any) (any, error) {
+	return qt.FindByPKContext(context.Background(), ids...)
+}
+
+// FindByPKContext 与 FindByPK 相同,使用 ctx 控制超时与取消
+func (qt *MySQL) FindByPKContext(ctx context.Context, ids ...any) (any, error) {
+	if len(ids) == 0 {
+		return nil, errors.New("primary key values required")
+	}
+	query, args, err := support.PrimaryKeyQuery(qt.Elems, '`', ids)
+	if err != nil {
+		return nil, err
+	}
+	if len(args) != len(support.PrimaryKeys(qt.Elems)) {
+		return nil, errors.New("FindByPK accepts exactly one primary key value per primary key column")
+	}
+	return qt.FindContext(ctx, append([]any{query}, args...)...)
+}
+
+// DeleteByPK 按主键删除记录
+// 单列主键可传入多个值批量删除;复合主键按声明顺序传入字段值,可传入字段数整数倍的值删除多条记录
+// 参数:
+//   - ids: 主键值
+//
+// 返回:
+//   - error: 执行错误
+func (qt *MySQL) DeleteByPK(ids ...any) error {
+	return qt.DeleteByPKContext(context.Background(), ids...)
+}
+
+// DeleteByPKContext 与 DeleteByPK 相同,使用 ctx 控制超时与取消
+func (qt *MySQL) DeleteByPKContext(ctx context.Context, ids ...any) error {
+	if len(ids) == 0 {
+		return errors.New("primary key values required")
+	}
+	query, args, err := support.PrimaryKeyQuery(qt.Elems, '`', ids)
+	if err != nil {
+		return err
+	}
+	return qt.DeleteContext(ctx, append([]any{query}, args...)...)
+}
+
 // Count 统计记录数量
 // 根据提供的查询条件统计匹配的记录数
 // 使用 MySQL 的 `table` 引用表名和 ? 占位符
@@ -374,31 +500,62 @@ func (qt *MySQL) Find(queryParts ...any) (any, error) {
 //   - int: 记录数量
 //   - error: 执行错误
 func (qt *MySQL) Count(queryParts ...any) (int, error) {
-	query, args := qt.buildQuery(queryParts...)
+	return qt.CountContext(context.Background(), queryParts...)
+}
 
-	// 计算 SQL 语句所需的缓冲区大小
-	tabNameLen := len(qt.Table)
-	queryStringLen := len(query)
-	var buf utils.Buffer
-	if query == "" {
-		buf = utils.NewBuffer(23 + tabNameLen) // SELECT COUNT(*) FROM `table`
+// CountContext 与 Count 相同,使用 ctx 控制超时与取消
+func (qt *MySQL) CountContext(ctx context.Context, queryParts ...any) (int, error) {
+	ctx, cancel := qt.config.Context(ctx)
+	defer cancel()
+
+	if qt.err != nil {
+		return 0, qt.err
+	}
+	q, err := qt.buildQuery(queryParts...)
+	if err != nil {
+		return 0, err
+	}
+	return qt.count(ctx, q)
+}
+
+// count 统计 q 匹配的记录数量,分组、限制行数或去重时统计子查询的行数
+func (qt *MySQL) count(ctx context.Context, q support.Query) (int, error) {
+	q.OrderBy = "" // 排序不影响计数
+	if err := qt.checkSets(ctx, &q); err != nil {
+		return 0, err
+	}
+
+	// DISTINCT 或集合运算时统计结果的行数
+	if qt.distinct || len(q.Sets) > 0 {
+		cp := *qt
+		cp.lock = support.Lock{} // 行锁只作用于返回行的查询
+		sqlStr := "SELECT COUNT(*) FROM (" + cp.getSelectSQL(&q) + ") AS `t`"
+		var counter int
+		err := qt.conn.QueryRowContext(ctx, sqlStr, q.Args()...).Scan(&counter)
+		return counter, support.TranslateError(err)
+	}
+
+	// 创建缓冲区并构建 COUNT 语句
+	buf := utils.NewBuffer(38 + len(qt.Table) + q.Len()) // SELECT COUNT(*) FROM (SELECT 1 FROM `table` ...) AS `t`
+	buf.WriteString(q.With)
+	wrap := q.HasClauses()
+	if wrap {
+		buf.WriteString("SELECT COUNT(*) FROM (SELECT 1")
 	} else {
-		buf = utils.NewBuffer(30 + tabNameLen + queryStringLen) // SELECT COUNT(*) FROM `table` WHERE
+		buf.WriteString("SELECT COUNT(*)")
 	}
-	buf.WriteString("SELECT COUNT(*) FROM `")
-	buf.WriteString(qt.Table)
-	buf.WriteString("`")
+	support.WriteFrom(&buf, '`', qt.tables())
 
-	// 构建 WHERE 子句
-	if query != "" {
-		buf.WriteString(" WHERE ")
-		buf.WriteString(query)
+	// 构建 WHERE 及其后的子句
+	q.WriteTo(&buf)
+	if wrap {
+		buf.WriteString(") AS `t`")
 	}
 
 	// 执行 COUNT 查询
 	var counter int
-	err := qt.conn.QueryRow(buf.String(), args).Scan(&counter)
-	return counter, err
+	err := qt.conn.QueryRowContext(ctx, buf.String(), q.Args()...).Scan(&counter)
+	return counter, support.TranslateError(err)
 }
 
 // autotType 根据目标类型和源值执行类型转换
@@ -448,49 +605,326 @@ func IsEmpty(err error) bool {
 	return false
 }
 
-// getSelectSQL 生成 SELECT 查询语句
+// primaryKeyQuery 生成按当前对象主键匹配的条件,未定义主键或主键为零值时返回空条件
+func (qt *MySQL) primaryKeyQuery() (string, []any) {
+	query, args, err := support.PrimaryKeyQuery(qt.Elems, '`', nil)
+	if err != nil {
+		return "", nil
+	}
+	return query, args
+}
+
+// writeQuery 解析 Update/Save/Delete 的条件
+// 未指定条件时按主键匹配当前对象,主键为零值时视为没有条件;仍没有条件且未允许全表写入时返回 support.ErrMissingWhere
+func (qt *MySQL) writeQuery(queryParts ...any) (string, []any, error) {
+	if qt.err != nil {
+		return "", nil, qt.err
+	}
+
+	if len(qt.joins) > 0 {
+		return "", nil, errors.New("JOIN is not supported in write operations")
+	}
+	if qt.from != "" {
+		return "", nil, errors.New("From is not supported in write operations")
+	}
+	if len(qt.sets) > 0 {
+		return "", nil, errors.New("set operations are not supported in write operations")
+	}
+	q, err := qt.buildQuery(queryParts...)
+	if err != nil {
+		return "", nil, err
+	}
+	if q.HasClauses() {
+		return "", nil, support.ErrWriteClause
+	}
+	query, args := q.Where, q.WhereArgs
+	// 未指定条件时按主键匹配当前对象
+	if query == "" {
+		query, args = qt.primaryKeyQuery()
+	}
+	if query == "" && !qt.global && !qt.config.AllowGlobal {
+		return "", nil, support.ErrMissingWhere
+	}
+	return query, args, nil
+}
+
+// buildUpdate 生成 UPDATE 语句
+// sets 不为空时只更新 Set 赋值的字段;否则 all 为 false 时仅更新非零值字段(Update),为 true 时更新所有非自增字段(Save)
+// 没有需要更新的字段时返回空字符串
+func (qt *MySQL) buildUpdate(query string, args []any, sets []support.Assignment, all bool) (string, []any, error) {
+	with, withArgs := support.WithClause('`', qt.ctes)
+	if len(sets) > 0 {
+		return qt.buildAssign(with, withArgs, query, args, sets)
+	}
+	elems := qt.columns()
+	all = all || qt.selected // Select 显式指定的字段写入零值
+
+	// 计算 SQL 语句所需的缓冲区大小
+	elemsLeng := len(elems)
+	elemsNameLength := 0
+	for i := 0; i < elemsLeng; i++ {
+		if !all && elems[i].Zero() {
+			continue
+		}
+		if elems[i].Option["autoIncrement"] == "-" {
+			continue
+		}
+		// 字段名(2个反引号) + `=?`(3) + 逗号(1)
+		elemsNameLength += len(elems[i].Tag) + 4 + 1
+	}
+	if elemsNameLength == 0 {
+		return "", nil, nil
+	}
+
+	// 创建缓冲区并构建 UPDATE 语句
+	buf := utils.NewBuffer(21 + len(qt.Table) + len(query) + elemsNameLength) // UPDATE `table` SET WHERE
+	buf.WriteString(with)
+	buf.WriteString("UPDATE `")
+	buf.WriteString(qt.Table)
+	buf.WriteString("` SET ")
+
+	// 收集需要更新的字段值并构建 SET 子句
+	values := make([]any, 0, len(withArgs)+elemsLeng+len(args))
+	values = append(values, withArgs...)
+	for i := 0; i < elemsLeng; i++ {
+		if !all && elems[i].Zero() {
+			continue
+		}
+		if elems[i].Option["autoIncrement"] == "-" {
+			continue
+		}
+		buf.WriteByte('`')
+		buf.WriteString(elems[i].Tag)
+		buf.WriteString("`=?")
+		buf.WriteByte(',')
+		values = append(values, elems[i].Get())
+	}
+	buf.TruncateLast(1) // 移除末尾的逗号
+
+	// 构建 WHERE 子句
+	if query != "" {
+		buf.WriteString(" WHERE ")
+		buf.WriteString(query)
+		values = append(values, args...)
+	}
+	return buf.String(), values, nil
+}
+
+// buildAssign 生成只更新 Set 赋值字段的 UPDATE 语句,字段值可以是 Expr 表达式
+func (qt *MySQL) buildAssign(with string, withArgs []any, query string, args []any, sets []support.Assignment) (string, []any, error) {
+	set := utils.NewBuffer(len(sets) * 16)
+	setArgs, err := support.WriteAssignments(&set, support.DialectMySQL, qt.Elems, sets)
+	if err != nil {
+		return "", nil, err
+	}
+
+	// 创建缓冲区并构建 UPDATE 语句
+	buf := utils.NewBuffer(21 + len(with) + len(qt.Table) + len(set) + len(query)) // UPDATE `table` SET WHERE
+	buf.WriteString(with)
+	buf.WriteString("UPDATE `")
+	buf.WriteString(qt.Table)
+	buf.WriteString("` SET ")
+	buf.WriteString(set.String())
+	values := make([]any, 0, len(withArgs)+len(setArgs)+len(args))
+	values = append(values, withArgs...)
+	values = append(values, setArgs...)
+
+	// 构建 WHERE 子句
+	if query != "" {
+		buf.WriteString(" WHERE ")
+		buf.WriteString(query)
+		values = append(values, args...)
+	}
+	return buf.String(), values, nil
+}
+
+// buildDelete 生成 DELETE 语句,返回的参数包含 WITH 子句的参数
+func (qt *MySQL) buildDelete(query string, args []any) (string, []any) {
+	with, withArgs := support.WithClause('`', qt.ctes)
+
+	// 计算 SQL 语句所需的缓冲区大小
+	tabNameLen := len(qt.Table)
+	queryStringLen := len(query)
+	var buf utils.Buffer
+	if query == "" {
+		buf = utils.NewBuffer(14 + tabNameLen) // DELETE FROM `table`
+	} else {
+		buf = utils.NewBuffer(20 + tabNameLen + queryStringLen) // DELETE FROM `table` WHERE
+	}
+	buf.WriteString(with)
+	buf.WriteString("DELETE FROM `")
+	buf.WriteString(qt.Table)
+	buf.WriteByte('`')
+
+	// 构建 WHERE 子句
+	if query != "" {
+		buf.WriteString(" WHERE ")
+		buf.WriteString(query)
+	}
+	if len(withArgs) > 0 {
+		args = append(withArgs, args...)
+	}
+	return buf.String(), args
+}
+
+// buildInsert 生成插入 inserts 字段的 INSERT 语句
+func (qt *MySQL) buildInsert(inserts []support.Elem) (string, []any) {
+	// 计算 SQL 语句所需的缓冲区大小
+	elemsLeng := len(inserts)
+	elemsNameLength := 0
+	for i := 0; i < elemsLeng; i++ {
+		// 字段名(2个反引号) + 逗号(1) + 占位符与逗号(2)
+		elemsNameLength += len(inserts[i].Tag) + 3 + 2
+	}
+
+	// 创建缓冲区并构建 INSERT 语句
+	buf := utils.NewBuffer(29 + len(qt.Table) + elemsNameLength) // INSERT INTO `table` (...) VALUES (...)
+	buf.WriteString("INSERT INTO `")
+	buf.WriteString(qt.Table)
+	buf.WriteString("` (")
+
+	// 所有字段均由数据库生成
+	if elemsLeng == 0 {
+		buf.WriteString(") VALUES ()")
+		return buf.String(), nil
+	}
+
+	// 构建字段列表
+	values := make([]any, 0, elemsLeng)
+	for i := 0; i < elemsLeng; i++ {
+		buf.WriteByte('`')
+		buf.WriteString(inserts[i].Tag)
+		buf.WriteByte('`')
+		values = append(values, inserts[i].Get())
+		buf.WriteByte(',')
+	}
+	buf.TruncateLast(1) // 移除末尾的逗号
+	buf.WriteString(") VALUES (")
+
+	// 构建 VALUES 子句,使用 MySQL 的 ? 占位符
+	for i := 0; i < elemsLeng; i++ {
+		buf.WriteByte('?')
+		buf.WriteByte(',')
+	}
+	buf.TruncateLast(1) // 移除末尾的逗号
+	buf.WriteByte(')')
+	return buf.String(), values
+}
+
+// refresh 按主键重新查询 elems 字段并写回对象,用于读取数据库生成的默认值
+// 未定义主键时跳过
+func (qt *MySQL) refresh(ctx context.Context, elems []support.Elem) error {
+	// 自增字段已由 LastInsertId 回写
+	cols := make([]support.Elem, 0, len(elems))
+	for i := 0; i < len(elems); i++ {
+		if elems[i].Option["autoIncrement"] != "-" {
+			cols = append(cols, elems[i])
+		}
+	}
+	query, args := qt.primaryKeyQuery()
+	if len(cols) == 0 || query == "" {
+		return nil
+	}
+
+	buf := utils.NewBuffer(22 + len(qt.Table) + len(query) + len(cols)*8)
+	buf.WriteString("SELECT ")
+	Scans := make([]any, len(cols))
+	for i := 0; i < len(cols); i++ {
+		buf.WriteByte('`')
+		buf.WriteString(cols[i].Tag)
+		buf.WriteString("`,")
+		Scans[i] = cols[i].GetInterface()
+	}
+	buf.TruncateLast(1) // 移除末尾的逗号
+	buf.WriteString(" FROM `")
+	buf.WriteString(qt.Table)
+	buf.WriteString("` WHERE ")
+	buf.WriteString(query)
+	return support.TranslateError(qt.conn.QueryRowContext(ctx, buf.String(), args...).Scan(Scans...))
+}
+
+// scanAll 将 rows 中的所有行扫描为新的对象
+func (qt *MySQL) scanAll(rows *sql.Rows) ([]any, error) {
+	elems := qt.columns()
+	elemsLen := len(elems)
+	var objs []any
+	for rows.Next() {
+		obj := reflect.New(qt.objType).Elem()
+		Scans := make([]any, elemsLen)
+		for i := 0; i < elemsLen; i++ {
+			// 使用反射获取字段的地址用于扫描
+			Scans[i] = reflect.NewAt(elems[i].Type, obj.Field(elems[i].Index).Addr().UnsafePointer()).Interface()
+		}
+		if err := rows.Scan(Scans...); err != nil {
+			return nil, support.TranslateError(err)
+		}
+		objs = append(objs, obj.Interface())
+	}
+	return objs, support.TranslateError(rows.Err())
+}
+
+// getSelectSQL 生成 SELECT 查询语句,同时将字段列表中表达式的参数写入 q.SelectArgs
 // 参数:
-//   - queryString: WHERE 子句的条件字符串
+//   - q: 查询条件,渲染为 WHERE 及其后的子句
 //
 // 返回:
 //   - string: 完整的 SELECT SQL 语句
-func (qt *MySQL) getSelectSQL(queryString string) string {
-	elemsLeng := len(qt.Elems)
+func (qt *MySQL) getSelectSQL(q *support.Query) string {
+	elems := qt.columns()
+	elemsLeng := len(elems)
 	tabNameLen := len(qt.Table)
-	queryStringLen := len(queryString)
 
 	// 计算 SQL 语句所需的缓冲区大小
 	elemsNameLength := 0
 	for i := 0; i < elemsLeng; i++ {
 		// 字段名(2个反引号) + 逗号(1)
-		elemsNameLength += len(qt.Elems[i].Tag) + 2 + 1
+		elemsNameLength += len(elems[i].Tag) + 2 + 1
 	}
 
 	// 创建缓冲区并构建 SELECT 语句
-	var buf utils.Buffer
-	if queryString == "" {
-		buf = utils.NewBuffer(15 + tabNameLen + elemsNameLength) // SELECT ... FROM `table`
+	buf := utils.NewBuffer(24 + tabNameLen + elemsNameLength + q.Len()) // SELECT ... FROM `table` WHERE ...
+	buf.WriteString(q.With)
+	start := len(buf)
+	if qt.distinct {
+		buf.WriteString("SELECT DISTINCT ")
 	} else {
-		buf = utils.NewBuffer(22 + tabNameLen + queryStringLen + elemsNameLength) // SELECT ... FROM `table` WHERE
+		buf.WriteString("SELECT ")
 	}
-	buf.WriteString("SELECT ")
 
-	// 构建字段列表
+	// 构建字段列表,连接其他表时以表名限定字段名,SelectExpr 指定的字段以表达式代替
+	q.SelectArgs = nil
 	for i := 0; i < elemsLeng; i++ {
+		if j := support.IndexExpr(qt.exprs, elems[i].Tag); j != -1 {
+			buf.WriteByte('(')
+			buf.WriteString(qt.exprs[j].SQL)
+			buf.WriteString(") AS `")
+			buf.WriteString(elems[i].Tag)
+			buf.WriteString("`,")
+			q.SelectArgs = append(q.SelectArgs, qt.exprs[j].Args...)
+			continue
+		}
+		if len(qt.joins) > 0 {
+			buf.WriteByte('`')
+			buf.WriteString(qt.source())
+			buf.WriteString("`.")
+		}
 		buf.WriteByte('`')
-		buf.WriteString(qt.Elems[i].Tag)
+		buf.WriteString(elems[i].Tag)
 		buf.WriteByte('`')
 		buf.WriteByte(',')
 	}
 	buf.TruncateLast(1) // 移除末尾的逗号
 
-	// 构建 FROM 和 WHERE 子句
-	buf.WriteString(" FROM `")
-	buf.WriteString(qt.Table)
-	buf.WriteString("`")
-	if queryString != "" {
-		buf.WriteString(" WHERE ")
-		buf.WriteString(queryString)
+	// 构建 FROM 及 JOIN 子句
+	support.WriteFrom(&buf, '`', qt.tables())
+
+	// 构建 WHERE、集合运算及其后的子句,集合运算的各个 SELECT 与主查询共用字段列表与 FROM 子句
+	q.WriteCompound(&buf, string(buf[start:]))
+
+	// 构建行锁子句
+	if lock := qt.lock.String(); lock != "" {
+		buf.WriteByte(' ')
+		buf.WriteString(lock)
 	}
 	return buf.String()
 }
@@ -0,0 +1,920 @@
+// Copyright 2024 OblivionOcean
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mysql_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/base64"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/OblivionOcean/opao"
+	"github.com/OblivionOcean/opao/internal/fakedb"
+	"github.com/OblivionOcean/opao/support"
+	"github.com/OblivionOcean/opao/support/mysql"
+)
+
+type User struct {
+	Id   int64  `db:"id" option:"primaryKey;autoIncrement"`
+	Name string `db:"name"`
+	Age  int    `db:"age"`
+}
+
+type Order struct {
+	Id     int64 `db:"id" option:"primaryKey;autoIncrement"`
+	UserId int64 `db:"user_id" option:"references=user.id"`
+	Amount int64 `db:"amount"`
+}
+
+// newDB 创建使用 fakedb 的 Database,注册 User 与 Order
+func newDB(t *testing.T) (*opao.Database, *fakedb.Driver) {
+	t.Helper()
+	d := &fakedb.Driver{Query: func(query string, args []any) (fakedb.Rows, error) {
+		if strings.Contains(query, "COUNT(*)") {
+			return fakedb.Value(int64(0)), nil
+		}
+		return fakedb.Rows{}, nil
+	}}
+	db := &opao.Database{Conn: fakedb.Open(d)}
+	db.ORM.Init(db.Conn, mysql.NewMySQL)
+	db.Config().Dialect = support.DialectMySQL
+	if err := db.Register("user", &User{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Register("order", &Order{}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	return db, d
+}
+
+// sqlCase 执行 run 后检查最后一条 SQL 与参数
+type sqlCase struct {
+	name string
+	run  func(db *opao.Database) error
+	sql  string
+	args []any
+}
+
+func runSQLCases(t *testing.T, cases []sqlCase) {
+	t.Helper()
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			db, d := newDB(t)
+			if err := c.run(db); err != nil {
+				t.Fatal(err)
+			}
+			checkCall(t, d.Last(), c.sql, c.args)
+		})
+	}
+}
+
+func checkCall(t *testing.T, call fakedb.Call, sql string, args []any) {
+	t.Helper()
+	if call.SQL != sql {
+		t.Errorf("sql:\n got %s\nwant %s", call.SQL, sql)
+	}
+	if len(call.Args) != 0 || len(args) != 0 {
+		if !reflect.DeepEqual(call.Args, args) {
+			t.Errorf("args: got %#v, want %#v", call.Args, args)
+		}
+	}
+}
+
+func TestCount(t *testing.T) {
+	count := func(parts ...any) func(db *opao.Database) error {
+		return func(db *opao.Database) error {
+			_, err := db.Load(&User{}).Count(parts...)
+			return err
+		}
+	}
+	runSQLCases(t, []sqlCase{
+		{"all", count(), "SELECT COUNT(*) FROM `user`", nil},
+		{"string", count("age > ?", 18), "SELECT COUNT(*) FROM `user` WHERE age > ?", []any{18}},
+		{"conditions", count(opao.Eq("name", "a"), opao.Gt("age", 1)), "SELECT COUNT(*) FROM `user` WHERE name = ? AND age > ?", []any{"a", 1}},
+		{"order ignored", count(opao.Gt("age", 1), opao.Desc("age")), "SELECT COUNT(*) FROM `user` WHERE age > ?", []any{1}},
+		{"group", count(opao.GroupBy("age")), "SELECT COUNT(*) FROM (SELECT 1 FROM `user` GROUP BY `age`) AS `t`", nil},
+		{"distinct", func(db *opao.Database) error {
+			_, err := db.Load(&User{}).Distinct("name").Count()
+			return err
+		}, "SELECT COUNT(*) FROM (SELECT DISTINCT `name` FROM `user`) AS `t`", nil},
+	})
+}
+
+func TestCountNotRegistered(t *testing.T) {
+	db, d := newDB(t)
+	type Unknown struct {
+		Id int64 `db:"id"`
+	}
+	if _, err := db.Load(&Unknown{}).Count(); err == nil || !strings.Contains(err.Error(), "not registered") {
+		t.Fatalf("got %v, want not registered error", err)
+	}
+	if len(d.Calls()) != 0 {
+		t.Fatalf("unexpected SQL: %v", d.Calls())
+	}
+}
+
+func TestCreateBatch(t *testing.T) {
+	db, d := newDB(t)
+	d.Query = func(query string, args []any) (fakedb.Rows, error) {
+		switch query {
+		case "SELECT @@max_allowed_packet":
+			return fakedb.Value("67108864"), nil
+		case "SELECT @@auto_increment_increment":
+			return fakedb.Value("2"), nil
+		}
+		return fakedb.Rows{}, nil
+	}
+	next := int64(10)
+	d.Exec = func(query string, args []any) (driver.Result, error) {
+		id := next
+		next += 10
+		return fakedb.Result{ID: id, Affected: int64(len(args) / 2)}, nil
+	}
+	users := []User{{Name: "a", Age: 1}, {Name: "b", Age: 2}, {Name: "c", Age: 3}}
+	if err := db.Load(&User{}).CreateBatch(users, 2); err != nil {
+		t.Fatal(err)
+	}
+
+	var inserts []fakedb.Call
+	for _, call := range d.Calls() {
+		if strings.HasPrefix(call.SQL, "INSERT") {
+			inserts = append(inserts, call)
+		}
+	}
+	if len(inserts) != 2 {
+		t.Fatalf("got %d INSERT statements, want 2", len(inserts))
+	}
+	checkCall(t, inserts[0], "INSERT INTO `user` (`name`,`age`) VALUES (?,?),(?,?)", []any{"a", 1, "b", 2})
+	checkCall(t, inserts[1], "INSERT INTO `user` (`name`,`age`) VALUES (?,?)", []any{"c", 3})
+	// 自增值按 auto_increment_increment 递增
+	if ids := []int64{users[0].Id, users[1].Id, users[2].Id}; !reflect.DeepEqual(ids, []int64{10, 12, 20}) {
+		t.Errorf("ids: got %v, want [10 12 20]", ids)
+	}
+}
+
+func TestCreateBatchNotRegistered(t *testing.T) {
+	db, d := newDB(t)
+	type Unknown struct {
+		Id int64 `db:"id"`
+	}
+	if err := db.Load(&Unknown{}).CreateBatch([]Unknown{{Id: 1}}, 0); err == nil {
+		t.Fatal("want error for unregistered model")
+	}
+	if len(d.Calls()) != 0 {
+		t.Fatalf("unexpected SQL: %v", d.Calls())
+	}
+}
+
+func TestUpsert(t *testing.T) {
+	upsert := func(u User, conflictColumns ...string) func(db *opao.Database) error {
+		return func(db *opao.Database) error {
+			return db.Load(&u).Upsert(conflictColumns...)
+		}
+	}
+	runSQLCases(t, []sqlCase{
+		{"primary key", upsert(User{Id: 5, Name: "a", Age: 1}), "INSERT INTO `user` (`id`,`name`,`age`) VALUES (?,?,?) ON DUPLICATE KEY UPDATE `name`=VALUES(`name`),`age`=VALUES(`age`),`id`=LAST_INSERT_ID(`id`)", []any{int64(5), "a", 1}},
+		{"zero primary key", upsert(User{Name: "a", Age: 1}), "INSERT INTO `user` (`name`,`age`) VALUES (?,?) ON DUPLICATE KEY UPDATE `name`=VALUES(`name`),`age`=VALUES(`age`),`id`=LAST_INSERT_ID(`id`)", []any{"a", 1}},
+		{"conflict on primary key", upsert(User{Name: "a", Age: 1}, "id"), "INSERT INTO `user` (`id`,`name`,`age`) VALUES (?,?,?) ON DUPLICATE KEY UPDATE `name`=VALUES(`name`),`age`=VALUES(`age`),`id`=LAST_INSERT_ID(`id`)", []any{int64(0), "a", 1}},
+		{"conflict on column", upsert(User{Name: "a", Age: 1}, "name"), "INSERT INTO `user` (`name`,`age`) VALUES (?,?) ON DUPLICATE KEY UPDATE `age`=VALUES(`age`),`id`=LAST_INSERT_ID(`id`)", []any{"a", 1}},
+	})
+}
+
+func TestUpsertNotRegistered(t *testing.T) {
+	db, d := newDB(t)
+	type Unknown struct {
+		Id int64 `db:"id"`
+	}
+	if err := db.Load(&Unknown{Id: 1}).Upsert(); err == nil {
+		t.Fatal("want error for unregistered model")
+	}
+	if len(d.Calls()) != 0 {
+		t.Fatalf("unexpected SQL: %v", d.Calls())
+	}
+}
+
+func TestFind(t *testing.T) {
+	find := func(u User, parts ...any) func(db *opao.Database) error {
+		return func(db *opao.Database) error {
+			_, err := db.Load(&u).Find(parts...)
+			if errors.Is(err, opao.ErrRecordNotFound) {
+				return nil
+			}
+			return err
+		}
+	}
+	runSQLCases(t, []sqlCase{
+		{"no condition ignores primary key", find(User{Id: 5}), "SELECT `id`,`name`,`age` FROM `user`", nil},
+		{"string", find(User{}, "name = ?", "a"), "SELECT `id`,`name`,`age` FROM `user` WHERE name = ?", []any{"a"}},
+		{"conditions", find(User{}, opao.Eq("name", "a"), opao.Desc("age")), "SELECT `id`,`name`,`age` FROM `user` WHERE name = ? ORDER BY `age` DESC", []any{"a"}},
+		{"by primary key", func(db *opao.Database) error {
+			_, err := db.Load(&User{}).FindByPK(5)
+			if errors.Is(err, opao.ErrRecordNotFound) {
+				return nil
+			}
+			return err
+		}, "SELECT `id`,`name`,`age` FROM `user` WHERE `id` = ?", []any{5}},
+	})
+}
+
+func TestFindNotFound(t *testing.T) {
+	db, _ := newDB(t)
+	if _, err := db.Load(&User{}).Find("id = ?", 1); !errors.Is(err, opao.ErrRecordNotFound) {
+		t.Fatalf("got %v, want ErrRecordNotFound", err)
+	}
+}
+
+func TestWritePrimaryKey(t *testing.T) {
+	runSQLCases(t, []sqlCase{
+		{"update", func(db *opao.Database) error {
+			return db.Load(&User{Id: 5, Name: "a"}).Update()
+		}, "UPDATE `user` SET `name`=? WHERE `id` = ?", []any{"a", int64(5)}},
+		{"delete", func(db *opao.Database) error {
+			return db.Load(&User{Id: 5}).Delete()
+		}, "DELETE FROM `user` WHERE `id` = ?", []any{int64(5)}},
+	})
+	db, d := newDB(t)
+	if err := db.Load(&User{Name: "a"}).Update(); !errors.Is(err, opao.ErrMissingWhere) {
+		t.Fatalf("update: got %v, want ErrMissingWhere", err)
+	}
+	if err := db.Load(&User{}).Delete(); !errors.Is(err, opao.ErrMissingWhere) {
+		t.Fatalf("delete: got %v, want ErrMissingWhere", err)
+	}
+	if len(d.Calls()) != 0 {
+		t.Fatalf("unexpected SQL: %v", d.Calls())
+	}
+}
+
+func TestLock(t *testing.T) {
+	db, d := newDB(t)
+	if _, err := db.Load(&User{}).ForUpdate().FindAll(); !errors.Is(err, opao.ErrLockOutsideTx) {
+		t.Fatalf("got %v, want ErrLockOutsideTx", err)
+	}
+	if len(d.Calls()) != 0 {
+		t.Fatalf("unexpected SQL: %v", d.Calls())
+	}
+	err := db.Transaction(context.Background(), func(tx *opao.Tx) error {
+		_, err := tx.Load(&User{}).ForUpdate().SkipLocked().FindAll(opao.Gt("age", 1))
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	calls := d.Calls()
+	if len(calls) != 3 {
+		t.Fatalf("got %d calls, want BEGIN, SELECT and COMMIT: %v", len(calls), calls)
+	}
+	checkCall(t, calls[1], "SELECT `id`,`name`,`age` FROM `user` WHERE age > ? FOR UPDATE SKIP LOCKED", []any{1})
+}
+
+type UserOrder struct {
+	User
+	Order *Order
+}
+
+func TestJoin(t *testing.T) {
+	scan := func(join func(db *opao.Database) support.ObjectORM, parts ...any) func(db *opao.Database) error {
+		return func(db *opao.Database) error {
+			var rows []UserOrder
+			return join(db).Scan(&rows, parts...)
+		}
+	}
+	runSQLCases(t, []sqlCase{
+		{"references", scan(func(db *opao.Database) support.ObjectORM {
+			return db.Load(&User{}).LeftJoin(&Order{})
+		}, opao.Gte("user.age", 18), opao.Desc("order.id")), "SELECT `user`.`id` AS `user.id`,`user`.`name` AS `user.name`,`user`.`age` AS `user.age`,`order`.`id` AS `order.id`,`order`.`user_id` AS `order.user_id`,`order`.`amount` AS `order.amount` FROM `user` LEFT JOIN `order` ON `order`.`user_id` = `user`.`id` WHERE user.age >= ? ORDER BY `order`.`id` DESC", []any{18}},
+		{"raw on", scan(func(db *opao.Database) support.ObjectORM {
+			return db.Load(&User{}).Join(&Order{}, "order.user_id = user.id")
+		}), "SELECT `user`.`id` AS `user.id`,`user`.`name` AS `user.name`,`user`.`age` AS `user.age`,`order`.`id` AS `order.id`,`order`.`user_id` AS `order.user_id`,`order`.`amount` AS `order.amount` FROM `user` JOIN `order` ON order.user_id = user.id", nil},
+		{"column pair", scan(func(db *opao.Database) support.ObjectORM {
+			return db.Load(&User{}).InnerJoin(&Order{}, "id", "user_id")
+		}), "SELECT `user`.`id` AS `user.id`,`user`.`name` AS `user.name`,`user`.`age` AS `user.age`,`order`.`id` AS `order.id`,`order`.`user_id` AS `order.user_id`,`order`.`amount` AS `order.amount` FROM `user` INNER JOIN `order` ON `user`.`id` = `order`.`user_id`", nil},
+		{"qualified column pair", scan(func(db *opao.Database) support.ObjectORM {
+			return db.Load(&User{}).Join(&Order{}, "user.id", "order.user_id")
+		}), "SELECT `user`.`id` AS `user.id`,`user`.`name` AS `user.name`,`user`.`age` AS `user.age`,`order`.`id` AS `order.id`,`order`.`user_id` AS `order.user_id`,`order`.`amount` AS `order.amount` FROM `user` JOIN `order` ON `user`.`id` = `order`.`user_id`", nil},
+	})
+}
+
+func TestJoinOnErrors(t *testing.T) {
+	db, d := newDB(t)
+	var rows []UserOrder
+	for _, on := range [][]string{{"id", "user_id", "amount"}, {"missing", "user_id"}, {"id", "missing"}, {"other.id", "user_id"}} {
+		if err := db.Load(&User{}).Join(&Order{}, on...).Scan(&rows); err == nil {
+			t.Errorf("%v: want error", on)
+		}
+	}
+	if len(d.Calls()) != 0 {
+		t.Fatalf("unexpected SQL: %v", d.Calls())
+	}
+}
+
+func TestJoinScanByName(t *testing.T) {
+	db, d := newDB(t)
+	// 列的顺序与查询的字段不同,按名称写入
+	d.Query = func(query string, args []any) (fakedb.Rows, error) {
+		return fakedb.Rows{
+			Columns: []string{"order.amount", "user.name", "order.id", "user.id", "order.user_id", "user.age"},
+			Values: [][]any{
+				{int64(100), "a", int64(7), int64(1), int64(1), int64(20)},
+				{nil, "b", nil, int64(2), nil, int64(30)},
+			},
+		}, nil
+	}
+	var rows []UserOrder
+	if err := db.Load(&User{}).LeftJoin(&Order{}).Scan(&rows); err != nil {
+		t.Fatal(err)
+	}
+	want := []UserOrder{
+		{User{Id: 1, Name: "a", Age: 20}, &Order{Id: 7, UserId: 1, Amount: 100}},
+		{User{Id: 2, Name: "b", Age: 30}, nil},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("got %+v, want %+v", rows, want)
+	}
+
+	d.Query = func(query string, args []any) (fakedb.Rows, error) {
+		return fakedb.Rows{Columns: []string{"user.id", "other"}, Values: [][]any{{int64(1), int64(2)}}}, nil
+	}
+	if err := db.Load(&User{}).LeftJoin(&Order{}).Scan(&rows); err == nil || !strings.Contains(err.Error(), "unknown column: other") {
+		t.Errorf("got %v, want unknown column error", err)
+	}
+}
+
+func TestSetOperation(t *testing.T) {
+	runSQLCases(t, []sqlCase{
+		{"union", func(db *opao.Database) error {
+			_, err := db.Load(&User{}).Union(opao.Gt("age", 60)).FindAll(opao.Lt("age", 18), opao.Asc("id"))
+			return err
+		}, "SELECT `id`,`name`,`age` FROM `user` WHERE age < ? UNION SELECT `id`,`name`,`age` FROM `user` WHERE age > ? ORDER BY `id` ASC", []any{18, 60}},
+	})
+
+	cases := []struct {
+		version string
+		ok      bool
+	}{
+		{"8.0.30", false},
+		{"8.0.31", true},
+		{"8.4.0-log", true},
+		{"10.2.44-MariaDB", false},
+		{"10.3.39-MariaDB-1:10.3.39+maria~ubu2004", true},
+		{"5.5.5-10.11.6-MariaDB", true},
+	}
+	for _, c := range cases {
+		t.Run(c.version, func(t *testing.T) {
+			db, d := newDB(t)
+			d.Query = func(query string, args []any) (fakedb.Rows, error) {
+				if query == "SELECT VERSION()" {
+					return fakedb.Value(c.version), nil
+				}
+				return fakedb.Rows{}, nil
+			}
+			// 构建查询时不访问数据库
+			orm := db.Load(&User{}).Intersect(opao.Gt("age", 60))
+			if len(d.Calls()) != 0 {
+				t.Fatalf("unexpected SQL: %v", d.Calls())
+			}
+			_, err := orm.FindAll(opao.Lt("age", 18))
+			if c.ok != (err == nil) {
+				t.Fatalf("got %v, want ok=%v", err, c.ok)
+			}
+			if !c.ok {
+				if calls := d.Calls(); len(calls) != 1 || calls[0].SQL != "SELECT VERSION()" {
+					t.Fatalf("unexpected SQL: %v", calls)
+				}
+				return
+			}
+			checkCall(t, d.Last(), "SELECT `id`,`name`,`age` FROM `user` WHERE age < ? INTERSECT SELECT `id`,`name`,`age` FROM `user` WHERE age > ?", []any{18, 60})
+		})
+	}
+}
+
+func TestSetOperationContext(t *testing.T) {
+	db, d := newDB(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := db.Load(&User{}).Except(opao.Gt("age", 60)).FindAllContext(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("got %v, want context.Canceled", err)
+	}
+	if len(d.Calls()) != 0 {
+		t.Fatalf("unexpected SQL: %v", d.Calls())
+	}
+}
+
+func TestRaw(t *testing.T) {
+	scan := func(query string, args ...any) func(db *opao.Database) error {
+		return func(db *opao.Database) error {
+			var users []User
+			return db.Raw(query, args...).Scan(&users)
+		}
+	}
+	named := func(query string, arg any) func(db *opao.Database) error {
+		return func(db *opao.Database) error {
+			var users []User
+			return db.RawNamed(query, arg).Scan(&users)
+		}
+	}
+	runSQLCases(t, []sqlCase{
+		{"placeholders", scan("SELECT * FROM users WHERE age > ? AND name = '?'", 18), "SELECT * FROM users WHERE age > ? AND name = '?'", []any{18}},
+		{"named", named("SELECT * FROM users WHERE age > :min AND name <> ':name'", map[string]any{"min": 18}), "SELECT * FROM users WHERE age > ? AND name <> ':name'", []any{18}},
+	})
+}
+
+func TestRawScan(t *testing.T) {
+	db, d := newDB(t)
+	d.Query = func(query string, args []any) (fakedb.Rows, error) {
+		return fakedb.Rows{
+			Columns: []string{"age", "extra", "id", "name"},
+			Values:  [][]any{{int64(20), "x", int64(1), "a"}, {nil, "y", int64(2), "b"}},
+		}, nil
+	}
+	var users []*User
+	if err := db.Raw("SELECT * FROM users").Scan(&users); err != nil {
+		t.Fatal(err)
+	}
+	want := []*User{{Id: 1, Name: "a", Age: 20}, {Id: 2, Name: "b"}}
+	if !reflect.DeepEqual(users, want) {
+		t.Errorf("got %+v, want %+v", users, want)
+	}
+	if err := db.Raw("SELECT * FROM users").Strict().Scan(&users); err == nil || !strings.Contains(err.Error(), "unknown column: extra") {
+		t.Errorf("got %v, want unknown column error", err)
+	}
+
+	d.Query = nil
+	var user User
+	if err := db.Raw("SELECT * FROM users").Scan(&user); !errors.Is(err, opao.ErrRecordNotFound) {
+		t.Errorf("got %v, want ErrRecordNotFound", err)
+	}
+}
+
+func TestNamedCondition(t *testing.T) {
+	find := func(parts ...any) func(db *opao.Database) error {
+		return func(db *opao.Database) error {
+			_, err := db.Load(&User{}).FindAll(parts...)
+			return err
+		}
+	}
+	runSQLCases(t, []sqlCase{
+		{"numbering", find(opao.Eq("id", 1), opao.Named("age > :min AND name <> ':name?'", map[string]any{"min": 18}), opao.Eq("name", "a")), "SELECT `id`,`name`,`age` FROM `user` WHERE id = ? AND age > ? AND name <> ':name?' AND name = ?", []any{1, 18, "a"}},
+		{"struct", find(opao.Named("name = :name", &User{Name: "a"})), "SELECT `id`,`name`,`age` FROM `user` WHERE name = ?", []any{"a"}},
+		{"quoted placeholder", find("name = '?' AND age > ?", 1), "SELECT `id`,`name`,`age` FROM `user` WHERE name = '?' AND age > ?", []any{1}},
+	})
+}
+
+func TestTranslateError(t *testing.T) {
+	db, d := newDB(t)
+	driverErr := errors.New("Error 1062 (23000): Duplicate entry 'a' for key 'name'")
+	d.Exec = func(query string, args []any) (driver.Result, error) { return nil, driverErr }
+	d.Query = func(query string, args []any) (fakedb.Rows, error) { return fakedb.Rows{}, driverErr }
+	if err := db.Load(&User{Name: "a"}).Create(); !errors.Is(err, opao.ErrDuplicateKey) || !errors.Is(err, driverErr) {
+		t.Errorf("create: got %v, want ErrDuplicateKey wrapping the driver error", err)
+	}
+	if err := db.Load(&User{Id: 1, Name: "a"}).Update(); !errors.Is(err, opao.ErrDuplicateKey) {
+		t.Errorf("update: got %v, want ErrDuplicateKey", err)
+	}
+}
+
+func TestTransaction(t *testing.T) {
+	const update = "UPDATE `user` SET `name`=? WHERE `id` = ?"
+	errFail := errors.New("fail")
+	update1 := func(tx *opao.Tx) error { return tx.Load(&User{Id: 1, Name: "a"}).Update() }
+	cases := []struct {
+		name  string
+		fn    func(tx *opao.Tx) error
+		err   error
+		panic bool
+		calls []string
+	}{
+		{"commit", update1, nil, false, []string{"BEGIN", update, "COMMIT"}},
+		{"rollback on error", func(tx *opao.Tx) error {
+			if err := update1(tx); err != nil {
+				return err
+			}
+			return errFail
+		}, errFail, false, []string{"BEGIN", update, "ROLLBACK"}},
+		{"rollback on panic", func(tx *opao.Tx) error {
+			_ = update1(tx)
+			panic("boom")
+		}, nil, true, []string{"BEGIN", update, "ROLLBACK"}},
+		{"nested release", func(tx *opao.Tx) error {
+			return tx.Transaction(context.Background(), update1)
+		}, nil, false, []string{"BEGIN", "SAVEPOINT opao_sp_1", update, "RELEASE SAVEPOINT opao_sp_1", "COMMIT"}},
+		{"nested rollback", func(tx *opao.Tx) error {
+			err := tx.Transaction(context.Background(), func(tx *opao.Tx) error {
+				return tx.Transaction(context.Background(), func(tx *opao.Tx) error {
+					_ = update1(tx)
+					return errFail
+				})
+			})
+			if !errors.Is(err, errFail) {
+				t.Errorf("nested: got %v, want %v", err, errFail)
+			}
+			return nil
+		}, nil, false, []string{"BEGIN", "SAVEPOINT opao_sp_1", "SAVEPOINT opao_sp_2", update, "ROLLBACK TO SAVEPOINT opao_sp_2", "ROLLBACK TO SAVEPOINT opao_sp_1", "COMMIT"}},
+		{"nested panic", func(tx *opao.Tx) error {
+			return tx.Transaction(context.Background(), func(tx *opao.Tx) error {
+				panic("boom")
+			})
+		}, nil, true, []string{"BEGIN", "SAVEPOINT opao_sp_1", "ROLLBACK TO SAVEPOINT opao_sp_1", "ROLLBACK"}},
+		{"nested options", func(tx *opao.Tx) error {
+			for _, opt := range []*sql.TxOptions{{Isolation: sql.LevelSerializable}, {ReadOnly: true}} {
+				if err := tx.Transaction(context.Background(), update1, opt); err == nil {
+					t.Errorf("%+v: want error", opt)
+				}
+			}
+			return tx.Transaction(context.Background(), update1, &sql.TxOptions{})
+		}, nil, false, []string{"BEGIN", "SAVEPOINT opao_sp_1", update, "RELEASE SAVEPOINT opao_sp_1", "COMMIT"}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			db, d := newDB(t)
+			var err error
+			func() {
+				defer func() {
+					if p := recover(); (p != nil) != c.panic {
+						t.Errorf("panic: got %v, want %v", p, c.panic)
+					}
+				}()
+				err = db.Transaction(context.Background(), c.fn)
+			}()
+			if !c.panic && !errors.Is(err, c.err) {
+				t.Errorf("got %v, want %v", err, c.err)
+			}
+			var calls []string
+			for _, call := range d.Calls() {
+				calls = append(calls, call.SQL)
+			}
+			if !reflect.DeepEqual(calls, c.calls) {
+				t.Errorf("calls:\n got %q\nwant %q", calls, c.calls)
+			}
+		})
+	}
+}
+
+func TestTransactionContext(t *testing.T) {
+	db, d := newDB(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	called := false
+	err := db.Transaction(ctx, func(tx *opao.Tx) error {
+		called = true
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) || called {
+		t.Fatalf("got %v (fn called: %v), want context.Canceled", err, called)
+	}
+	if len(d.Calls()) != 0 {
+		t.Fatalf("unexpected SQL: %v", d.Calls())
+	}
+}
+
+// userRows 返回 n 行 User 结果
+func userRows(n int) fakedb.Rows {
+	rows := fakedb.Rows{Columns: []string{"id", "name", "age"}}
+	for i := 1; i <= n; i++ {
+		rows.Values = append(rows.Values, []any{int64(i), "u", int64(i)})
+	}
+	return rows
+}
+
+func TestEach(t *testing.T) {
+	for _, reuse := range []bool{false, true} {
+		db, d := newDB(t)
+		d.Query = func(string, []any) (fakedb.Rows, error) { return userRows(3), nil }
+		orm := db.Load(&User{})
+		if reuse {
+			orm = orm.Reuse()
+		}
+		var ptrs []*User
+		var ids []int64
+		err := orm.Each(func(obj any) error {
+			u := obj.(*User)
+			ptrs = append(ptrs, u)
+			ids = append(ids, u.Id)
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(ids, []int64{1, 2, 3}) {
+			t.Errorf("reuse %v: ids got %v", reuse, ids)
+		}
+		if same := ptrs[0] == ptrs[1] && ptrs[1] == ptrs[2]; same != reuse {
+			t.Errorf("reuse %v: same object for every row: %v", reuse, same)
+		}
+	}
+}
+
+func TestEachStop(t *testing.T) {
+	db, d := newDB(t)
+	d.Query = func(string, []any) (fakedb.Rows, error) { return userRows(3), nil }
+	errStop := errors.New("stop")
+	n := 0
+	err := db.Load(&User{}).Each(func(obj any) error {
+		n++
+		return errStop
+	})
+	if !errors.Is(err, errStop) || n != 1 {
+		t.Fatalf("got %v after %d rows, want errStop after 1 row", err, n)
+	}
+}
+
+func TestEachTimeout(t *testing.T) {
+	db, d := newDB(t)
+	d.Query = func(string, []any) (fakedb.Rows, error) { return userRows(3), nil }
+	db.SetTimeout(20 * time.Millisecond)
+	n := 0
+	err := db.Load(&User{}).Each(func(obj any) error {
+		n++
+		time.Sleep(15 * time.Millisecond)
+		return nil
+	})
+	if err != nil || n != 3 {
+		t.Fatalf("got %v after %d rows, want all 3 rows", err, n)
+	}
+}
+
+func TestMissingWhere(t *testing.T) {
+	cases := []struct {
+		name string
+		run  func(orm support.ObjectORM) error
+	}{
+		{"update", func(orm support.ObjectORM) error { return orm.Update() }},
+		{"save", func(orm support.ObjectORM) error { return orm.Save() }},
+		{"delete", func(orm support.ObjectORM) error { return orm.Delete() }},
+		{"update columns", func(orm support.ObjectORM) error {
+			return orm.UpdateColumns(map[string]any{"age": 1})
+		}},
+		{"delete returning", func(orm support.ObjectORM) error {
+			_, err := orm.DeleteReturning()
+			return err
+		}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			db, d := newDB(t)
+			if err := c.run(db.Load(&User{Name: "a"})); !errors.Is(err, opao.ErrMissingWhere) {
+				t.Fatalf("got %v, want ErrMissingWhere", err)
+			}
+			if len(d.Calls()) != 0 {
+				t.Fatalf("unexpected SQL: %v", d.Calls())
+			}
+		})
+	}
+}
+
+func TestAllowGlobal(t *testing.T) {
+	runSQLCases(t, []sqlCase{
+		{"allow global", func(db *opao.Database) error {
+			return db.Load(&User{Name: "a"}).AllowGlobal().Update()
+		}, "UPDATE `user` SET `name`=?", []any{"a"}},
+		{"set allow global", func(db *opao.Database) error {
+			db.SetAllowGlobal(true)
+			return db.Load(&User{}).Delete()
+		}, "DELETE FROM `user`", nil},
+	})
+}
+
+func TestCreateLastInsertId(t *testing.T) {
+	db, d := newDB(t)
+	d.Exec = func(string, []any) (driver.Result, error) { return fakedb.Result{ID: 42, Affected: 1}, nil }
+	u := &User{Name: "a", Age: 1}
+	res, err := db.Load(u).CreateWithResult()
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkCall(t, d.Last(), "INSERT INTO `user` (`name`,`age`) VALUES (?,?)", []any{"a", 1})
+	if u.Id != 42 || res.LastInsertId != 42 {
+		t.Errorf("got id %d, result %+v, want 42", u.Id, res)
+	}
+}
+
+func TestUpdateReturning(t *testing.T) {
+	db, d := newDB(t)
+	d.Query = func(query string, args []any) (fakedb.Rows, error) {
+		if strings.HasPrefix(query, "SELECT `id` FROM") {
+			return fakedb.Rows{Columns: []string{"id"}, Values: [][]any{{int64(1)}, {int64(2)}}}, nil
+		}
+		return userRows(2), nil
+	}
+	objs, err := db.Load(&User{}).UpdateReturning(opao.Set("age", 3), "name = ?", "u")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 先查询主键,更新后按主键重新查询
+	calls := d.Calls()
+	if len(calls) != 3 {
+		t.Fatalf("got %d calls, want 3: %v", len(calls), calls)
+	}
+	checkCall(t, calls[0], "SELECT `id` FROM `user` WHERE name = ?", []any{"u"})
+	checkCall(t, calls[1], "UPDATE `user` SET `age`=? WHERE name = ?", []any{3, "u"})
+	checkCall(t, calls[2], "SELECT `id`,`name`,`age` FROM `user` WHERE `id` IN (?,?)", []any{int64(1), int64(2)})
+	if len(objs) != 2 || objs[1].(User).Id != 2 {
+		t.Errorf("got objs %v", objs)
+	}
+}
+
+func TestDeleteReturning(t *testing.T) {
+	db, d := newDB(t)
+	d.Query = func(string, []any) (fakedb.Rows, error) { return userRows(2), nil }
+	objs, err := db.Load(&User{}).DeleteReturning("name = ?", "u")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 先查询匹配的记录再删除
+	calls := d.Calls()
+	if len(calls) != 2 {
+		t.Fatalf("got %d calls, want 2: %v", len(calls), calls)
+	}
+	checkCall(t, calls[0], "SELECT `id`,`name`,`age` FROM `user` WHERE name = ?", []any{"u"})
+	checkCall(t, calls[1], "DELETE FROM `user` WHERE name = ?", []any{"u"})
+	if len(objs) != 2 || objs[1].(User).Id != 2 {
+		t.Errorf("got objs %v", objs)
+	}
+}
+
+func TestPaginate(t *testing.T) {
+	db, d := newDB(t)
+	d.Query = func(query string, args []any) (fakedb.Rows, error) {
+		if strings.Contains(query, "COUNT(*)") {
+			return fakedb.Value(int64(5)), nil
+		}
+		return userRows(2), nil
+	}
+	page, err := db.Load(&User{}).Paginate(2, 2, "age > ?", 1, opao.Asc("id"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkCall(t, d.Last(), "SELECT `id`,`name`,`age` FROM `user` WHERE age > ? ORDER BY `id` ASC LIMIT ? OFFSET ?", []any{1, 2, 2})
+	if page.Total != 5 || page.Pages != 3 || len(page.Items) != 2 {
+		t.Errorf("got %+v", page)
+	}
+}
+
+func TestCursorPaginate(t *testing.T) {
+	db, d := newDB(t)
+	d.Query = func(string, []any) (fakedb.Rows, error) { return userRows(3), nil }
+	parts := []any{"name = ?", "u", opao.Desc("age"), opao.Asc("id")}
+	first, err := db.Load(&User{}).CursorPaginate("", 2, parts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkCall(t, d.Last(), "SELECT `id`,`name`,`age` FROM `user` WHERE name = ? ORDER BY `age` DESC,`id` ASC LIMIT ?", []any{"u", 3})
+	if !first.HasNext || len(first.Items) != 2 || first.NextCursor == "" {
+		t.Fatalf("got %+v", first)
+	}
+
+	// 游标条件与原 WHERE 组合,降序字段取小于游标值的行
+	if _, err = db.Load(&User{}).CursorPaginate(first.NextCursor, 2, parts...); err != nil {
+		t.Fatal(err)
+	}
+	checkCall(t, d.Last(), "SELECT `id`,`name`,`age` FROM `user` WHERE (name = ?) AND ((`age` < ?) OR (`age` = ? AND `id` > ?)) ORDER BY `age` DESC,`id` ASC LIMIT ?", []any{"u", 2, 2, int64(2), 3})
+}
+
+func TestCursorErrors(t *testing.T) {
+	db, d := newDB(t)
+	d.Query = func(string, []any) (fakedb.Rows, error) { return userRows(2), nil }
+	first, err := db.Load(&User{}).CursorPaginate("", 1, opao.Asc("id"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		name   string
+		cursor string
+		order  []any
+	}{
+		{"tampered encoding", first.NextCursor + "!", []any{opao.Asc("id")}},
+		{"tampered value", base64.RawURLEncoding.EncodeToString([]byte(`{"c":["id"],"v":["x"]}`)), []any{opao.Asc("id")}},
+		{"other column", first.NextCursor, []any{opao.Asc("age")}},
+		{"extra column", first.NextCursor, []any{opao.Desc("age"), opao.Asc("id")}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			d.Reset()
+			if _, err := db.Load(&User{}).CursorPaginate(c.cursor, 1, c.order...); err == nil {
+				t.Fatal("want error")
+			}
+			if len(d.Calls()) != 0 {
+				t.Fatalf("unexpected SQL: %v", d.Calls())
+			}
+		})
+	}
+}
+
+func TestCTE(t *testing.T) {
+	adults := opao.Subquery(&User{}, "id", "age > ?", 18)
+	findAll := func(orm func(db *opao.Database) support.ObjectORM, parts ...any) func(db *opao.Database) error {
+		return func(db *opao.Database) error {
+			_, err := orm(db).FindAll(parts...)
+			return err
+		}
+	}
+	// 公用表表达式的参数排在语句其余参数之前
+	runSQLCases(t, []sqlCase{
+		{"find all", findAll(func(db *opao.Database) support.ObjectORM {
+			return db.Load(&User{}).With("adults", adults)
+		}, "id IN (SELECT id FROM adults) AND name = ?", "a"), "WITH `adults` AS (SELECT `id` FROM `user` WHERE age > ?) SELECT `id`,`name`,`age` FROM `user` WHERE id IN (SELECT id FROM adults) AND name = ?", []any{18, "a"}},
+		{"update", func(db *opao.Database) error {
+			return db.Load(&User{Name: "b"}).With("adults", adults).Update("id IN (SELECT id FROM adults) AND name = ?", "a")
+		}, "WITH `adults` AS (SELECT `id` FROM `user` WHERE age > ?) UPDATE `user` SET `name`=? WHERE id IN (SELECT id FROM adults) AND name = ?", []any{18, "b", "a"}},
+		{"delete", func(db *opao.Database) error {
+			return db.Load(&User{}).With("adults", adults).Delete("id IN (SELECT id FROM adults) AND name = ?", "a")
+		}, "WITH `adults` AS (SELECT `id` FROM `user` WHERE age > ?) DELETE FROM `user` WHERE id IN (SELECT id FROM adults) AND name = ?", []any{18, "a"}},
+		{"recursive from", findAll(func(db *opao.Database) support.ObjectORM {
+			return db.Load(&User{}).WithRecursive("tree(id,name,age)", opao.Custom(
+				"SELECT id,name,age FROM `user` WHERE id = ? UNION ALL SELECT u.id,u.name,u.age FROM `user` u JOIN tree t ON u.age = t.id", 1,
+			)).From("tree")
+		}, "age > ?", 2), "WITH RECURSIVE `tree`(id,name,age) AS (SELECT id,name,age FROM `user` WHERE id = ? UNION ALL SELECT u.id,u.name,u.age FROM `user` u JOIN tree t ON u.age = t.id) SELECT `id`,`name`,`age` FROM `tree` WHERE age > ?", []any{1, 2}},
+		{"from", findAll(func(db *opao.Database) support.ObjectORM {
+			return db.Load(&User{}).From("active_user")
+		}, "age > ?", 2), "SELECT `id`,`name`,`age` FROM `active_user` WHERE age > ?", []any{2}},
+	})
+}
+
+func TestFromWrite(t *testing.T) {
+	db, d := newDB(t)
+	if err := db.Load(&User{Id: 1, Name: "a"}).From("active_user").Update(); err == nil {
+		t.Fatal("update: want error for From")
+	}
+	if err := db.Load(&User{Id: 1}).From("active_user").Delete(); err == nil {
+		t.Fatal("delete: want error for From")
+	}
+	if len(d.Calls()) != 0 {
+		t.Fatalf("unexpected S`L: %v", d.Calls())
+	}
+}
+
+func TestExpr(t *testing.T) {
+	findAll := func(exprs ...support.Expr) func(db *opao.Database) error {
+		return func(db *opao.Database) error {
+			_, err := db.Load(&User{}).SelectExpr(exprs...).FindAll("age > ?", 2)
+			return err
+		}
+	}
+	runSQLCases(t, []sqlCase{
+		{"set expr", func(db *opao.Database) error {
+			return db.Load(&User{}).Update(opao.Set("age", opao.Expr("age + ?", 1)), "name = ?", "a")
+		}, "UPDATE `user` SET `age`=age + ? WHERE name = ?", []any{1, "a"}},
+		{"set coalesce", func(db *opao.Database) error {
+			return db.Load(&User{}).Update(opao.Set("name", opao.Coalesce("name", "anon")), opao.Set("age", 3), "id = ?", 1)
+		}, "UPDATE `user` SET `name`=COALESCE(`name`,?),`age`=? WHERE id = ?", []any{"anon", 3, 1}},
+		{"select coalesce", findAll(opao.Coalesce("name", opao.Expr("?", "anon")).As("name")), "SELECT `id`,(COALESCE(`name`,?)) AS `name`,`age` FROM `user` WHERE age > ?", []any{"anon", 2}},
+		{"select now", findAll(opao.Now().As("name")), "SELECT `id`,(NOW()) AS `name`,`age` FROM `user` WHERE age > ?", []any{2}},
+		{"select date trunc", findAll(opao.DateTrunc("month", opao.Now()).As("name"), opao.Expr("age * ?", 2).As("age")), "SELECT `id`,(CAST(DATE_FORMAT(NOW(),'%Y-%m-01') AS DATETIME)) AS `name`,(age * ?) AS `age` FROM `user` WHERE age > ?", []any{2, 2}},
+	})
+}
+
+func TestUpdateColumns(t *testing.T) {
+	// 零值同样写入
+	runSQLCases(t, []sqlCase{
+		{"zero values", func(db *opao.Database) error {
+			return db.Load(&User{Id: 5}).UpdateColumns(map[string]any{"age": 0, "name": ""})
+		}, "UPDATE `user` SET `name`=?,`age`=? WHERE `id` = ?", []any{"", 0, int64(5)}},
+		{"with condition", func(db *opao.Database) error {
+			return db.Load(&User{}).UpdateColumns(map[string]any{"age": opao.Expr("age + ?", 1)}, "name = ?", "a")
+		}, "UPDATE `user` SET `age`=age + ? WHERE name = ?", []any{1, "a"}},
+		{"fields", func(db *opao.Database) error {
+			return db.Load(&User{Id: 5, Name: "a"}).UpdateFields("age")
+		}, "UPDATE `user` SET `age`=? WHERE `id` = ?", []any{0, int64(5)}},
+	})
+}
+
+func TestUpdateColumnsErrors(t *testing.T) {
+	cases := []struct {
+		name string
+		run  func(orm support.ObjectORM) error
+	}{
+		{"unknown column", func(orm support.ObjectORM) error {
+			return orm.UpdateColumns(map[string]any{"age": 1, "nickname": "a"})
+		}},
+		{"unknown field", func(orm support.ObjectORM) error { return orm.UpdateFields("nickname") }},
+		{"no fields", func(orm support.ObjectORM) error { return orm.UpdateFields() }},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			db, d := newDB(t)
+			if err := c.run(db.Load(&User{Id: 5})); err == nil {
+				t.Fatal("want error")
+			}
+			if len(d.Calls()) != 0 {
+				t.Fatalf("unexpected SQL: %v", d.Calls())
+			}
+		})
+	}
+}
@@ -0,0 +1,157 @@
+// Copyright 2024 OblivionOcean
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mysql
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/OblivionOcean/opao/support"
+	"github.com/OblivionOcean/opao/utils"
+)
+
+// UpdateReturning 更新记录并返回更新后的记录
+// MySQL 不支持 RETURNING,先查询匹配记录的主键,更新后再按主键查询;
+// 要求模型定义主键,且多条语句之间不保证原子性,需要时请在事务中调用
+// 参数:
+//   - queryParts: 查询条件部分,可以是条件字符串和参数
+//
+// 返回:
+//   - []any: 更新后的记录
+//   - error: 执行错误
+func (qt *MySQL) UpdateReturning(queryParts ...any) ([]any, error) {
+	return qt.UpdateReturningContext(context.Background(), queryParts...)
+}
+
+// UpdateReturningContext 与 UpdateReturning 相同,使用 ctx 控制超时与取消
+func (qt *MySQL) UpdateReturningContext(ctx context.Context, queryParts ...any) ([]any, error) {
+	ctx, cancel := qt.config.Context(ctx)
+	defer cancel()
+
+	queryParts, sets := support.SplitAssignments(queryParts)
+	query, args, err := qt.writeQuery(queryParts...)
+	if err != nil {
+		return nil, err
+	}
+	sqlStr, values, err := qt.buildUpdate(query, args, sets, false)
+	if err != nil {
+		return nil, err
+	}
+	if sqlStr == "" {
+		return nil, nil
+	}
+
+	// 先记录匹配记录的主键,更新后条件字段可能已经改变
+	ids, err := qt.primaryKeys(ctx, query, args)
+	if err != nil || len(ids) == 0 {
+		return nil, err
+	}
+	if _, err = qt.conn.ExecContext(ctx, sqlStr, values...); err != nil {
+		return nil, support.TranslateError(err)
+	}
+	pkQuery, pkArgs, err := support.PrimaryKeyQuery(qt.Elems, '`', ids)
+	if err != nil {
+		return nil, err
+	}
+	return qt.findAll(ctx, &support.Query{Where: pkQuery, WhereArgs: pkArgs})
+}
+
+// DeleteReturning 删除记录并返回被删除的记录
+// MySQL 不支持 RETURNING,先查询匹配的记录再删除;
+// 多条语句之间不保证原子性,需要时请在事务中调用
+// 参数:
+//   - queryParts: 查询条件部分,可以是条件字符串和参数
+//
+// 返回:
+//   - []any: 被删除的记录
+//   - error: 执行错误
+func (qt *MySQL) DeleteReturning(queryParts ...any) ([]any, error) {
+	return qt.DeleteReturningContext(context.Background(), queryParts...)
+}
+
+// DeleteReturningContext 与 DeleteReturning 相同,使用 ctx 控制超时与取消
+func (qt *MySQL) DeleteReturningContext(ctx context.Context, queryParts ...any) ([]any, error) {
+	ctx, cancel := qt.config.Context(ctx)
+	defer cancel()
+
+	query, args, err := qt.writeQuery(queryParts...)
+	if err != nil {
+		return nil, err
+	}
+	q := support.Query{Where: query, WhereArgs: args}
+	q.With, q.WithArgs = support.WithClause('`', qt.ctes)
+	objs, err := qt.findAll(ctx, &q)
+	if err != nil || len(objs) == 0 {
+		return nil, err
+	}
+	sqlStr, args := qt.buildDelete(query, args)
+	if _, err = qt.conn.ExecContext(ctx, sqlStr, args...); err != nil {
+		return nil, support.TranslateError(err)
+	}
+	return objs, nil
+}
+
+// primaryKeys 查询匹配 query 的记录的主键值,复合主键的值按记录依次展开
+func (qt *MySQL) primaryKeys(ctx context.Context, query string, args []any) ([]any, error) {
+	pks := support.PrimaryKeys(qt.Elems)
+	if len(pks) == 0 {
+		return nil, support.ErrNoPrimaryKey
+	}
+
+	// 条件可能引用 WITH 子句定义的公用表表达式
+	with, withArgs := support.WithClause('`', qt.ctes)
+	if len(withArgs) > 0 {
+		args = append(withArgs, args...)
+	}
+
+	buf := utils.NewBuffer(22 + len(with) + len(qt.Table) + len(query) + len(pks)*8)
+	buf.WriteString(with)
+	buf.WriteString("SELECT ")
+	for i := 0; i < len(pks); i++ {
+		buf.WriteByte('`')
+		buf.WriteString(qt.Elems[pks[i]].Tag)
+		buf.WriteString("`,")
+	}
+	buf.TruncateLast(1) // 移除末尾的逗号
+	buf.WriteString(" FROM `")
+	buf.WriteString(qt.Table)
+	buf.WriteByte('`')
+	if query != "" {
+		buf.WriteString(" WHERE ")
+		buf.WriteString(query)
+	}
+
+	rows, err := qt.conn.QueryContext(ctx, buf.String(), args...)
+	if err != nil {
+		return nil, support.TranslateError(err)
+	}
+	defer func(rows *sql.Rows) {
+		_ = rows.Close()
+	}(rows)
+
+	var ids []any
+	Scans := make([]any, len(pks))
+	for rows.Next() {
+		row := make([]any, len(pks))
+		for i := 0; i < len(pks); i++ {
+			Scans[i] = &row[i]
+		}
+		if err := rows.Scan(Scans...); err != nil {
+			return nil, support.TranslateError(err)
+		}
+		ids = append(ids, row...)
+	}
+	return ids, support.TranslateError(rows.Err())
+}
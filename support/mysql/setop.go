@@ -0,0 +1,83 @@
+// Copyright 2024 OblivionOcean
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mysql
+
+import (
+	"context"
+	"errors"
+
+	"github.com/OblivionOcean/opao/support"
+)
+
+// Union 返回与 queryParts 匹配的记录合并去重的 ObjectORM 副本
+// queryParts 只能包含 WHERE 条件,追加的 SELECT 与主查询使用相同的字段、表与 JOIN;
+// 查询时传入的条件作用于第一个 SELECT,ORDER BY 与 LIMIT 作用于集合运算的结果,不支持 GROUP BY、HAVING 与游标分页
+// 多个集合运算的优先级由数据库决定,写操作与聚合查询不支持集合运算
+func (qt *MySQL) Union(queryParts ...any) support.ObjectORM {
+	return qt.setOperation(support.SetUnion, queryParts)
+}
+
+// UnionAll 与 Union 相同,但保留重复的记录
+func (qt *MySQL) UnionAll(queryParts ...any) support.ObjectORM {
+	return qt.setOperation(support.SetUnionAll, queryParts)
+}
+
+// Intersect 返回与 queryParts 匹配的记录取交集的 ObjectORM 副本,参见 Union
+// 需要 MySQL 8.0.31 或 MariaDB 10.3 及以上版本,否则执行查询时返回错误
+func (qt *MySQL) Intersect(queryParts ...any) support.ObjectORM {
+	return qt.setOperation(support.SetIntersect, queryParts)
+}
+
+// Except 返回排除与 queryParts 匹配的记录的 ObjectORM 副本,参见 Union
+// 需要 MySQL 8.0.31 或 MariaDB 10.3 及以上版本,否则执行查询时返回错误
+func (qt *MySQL) Except(queryParts ...any) support.ObjectORM {
+	return qt.setOperation(support.SetExcept, queryParts)
+}
+
+// setOperation 返回追加了一个集合运算的副本,服务器是否支持在执行查询时检查
+func (qt *MySQL) setOperation(op string, queryParts []any) support.ObjectORM {
+	cp := *qt
+	if qt.err != nil {
+		return &cp
+	}
+	cp.sets = append(qt.sets[:len(qt.sets):len(qt.sets)], support.SetOperation{Op: op, Parts: queryParts})
+	return &cp
+}
+
+// checkSets 检查服务器是否支持 q 中的集合运算
+// INTERSECT 与 EXCEPT 从 MySQL 8.0.31、MariaDB 10.3 开始支持,版本号在首次使用时查询并缓存
+func (qt *MySQL) checkSets(ctx context.Context, q *support.Query) error {
+	for i := 0; i < len(q.Sets); i++ {
+		op := q.Sets[i].Op
+		if op != support.SetIntersect && op != support.SetExcept {
+			continue
+		}
+		version, err := qt.config.ServerInfo(ctx, qt.conn, "SELECT VERSION()")
+		if err != nil {
+			return err
+		}
+		if v, ok := support.MariaDBVersion(version); ok {
+			if !support.VersionAtLeast(v, 10, 3) {
+				return errors.New(op + " requires MariaDB 10.3 or later, server version is " + version)
+			}
+			return nil
+		}
+		if !support.VersionAtLeast(version, 8, 0, 31) {
+			return errors.New(op + " requires MySQL 8.0.31 or later, server version is " + version)
+		}
+		return nil
+	}
+	return nil
+}
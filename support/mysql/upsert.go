@@ -0,0 +1,127 @@
+// Copyright 2024 OblivionOcean
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mysql
+
+import (
+	"context"
+
+	"github.com/OblivionOcean/opao/support"
+	"github.com/OblivionOcean/opao/utils"
+)
+
+// OnConflictDoNothing 返回 Upsert 冲突时不做任何操作的 ObjectORM 副本
+// MySQL 使用 INSERT IGNORE 实现,注意它同样会忽略其他可降级为警告的错误
+func (qt *MySQL) OnConflictDoNothing() support.ObjectORM {
+	cp := *qt
+	cp.conflict = support.Conflict{DoNothing: true}
+	return &cp
+}
+
+// OnConflictUpdate 返回 Upsert 冲突时仅更新 columns 字段的 ObjectORM 副本
+func (qt *MySQL) OnConflictUpdate(columns ...string) support.ObjectORM {
+	cp := *qt
+	cp.conflict = support.Conflict{Update: columns}
+	return &cp
+}
+
+// Upsert 插入记录,唯一键冲突时更新已有记录
+// MySQL 根据表上的任意主键或唯一索引判断冲突,conflictColumns 仅用于排除不需要更新的字段
+// 生成 INSERT ... ON DUPLICATE KEY UPDATE `col`=VALUES(`col`) 语句
+// 自增字段通过 LAST_INSERT_ID(`col`) 回写,冲突更新时同样可以取得已有记录的值
+// 参数:
+//   - conflictColumns: 冲突判断字段
+//
+// 返回:
+//   - error: 执行错误
+func (qt *MySQL) Upsert(conflictColumns ...string) error {
+	return qt.UpsertContext(context.Background(), conflictColumns...)
+}
+
+// UpsertContext 与 Upsert 相同,使用 ctx 控制超时与取消
+func (qt *MySQL) UpsertContext(ctx context.Context, conflictColumns ...string) error {
+	ctx, cancel := qt.config.Context(ctx)
+	defer cancel()
+
+	if qt.err != nil {
+		return qt.err
+	}
+	inserts, updates, err := support.UpsertColumns(qt.Elems, conflictColumns, qt.conflict)
+	if err != nil {
+		return err
+	}
+	autoInc := -1
+	for i := 0; i < len(qt.Elems); i++ {
+		if qt.Elems[i].Option["autoIncrement"] == "-" {
+			autoInc = i
+			break
+		}
+	}
+
+	// 构建 INSERT 语句
+	buf := utils.NewBuffer(64 + len(qt.Table) + len(inserts)*16 + len(updates)*24)
+	if len(updates) == 0 {
+		buf.WriteString("INSERT IGNORE INTO `")
+	} else {
+		buf.WriteString("INSERT INTO `")
+	}
+	buf.WriteString(qt.Table)
+	buf.WriteString("` (")
+	values := make([]any, 0, len(inserts))
+	for i := 0; i < len(inserts); i++ {
+		buf.WriteByte('`')
+		buf.WriteString(inserts[i].Tag)
+		buf.WriteString("`,")
+		values = append(values, inserts[i].Get())
+	}
+	buf.TruncateLast(1) // 移除末尾的逗号
+	buf.WriteString(") VALUES (")
+	for i := 0; i < len(inserts); i++ {
+		buf.WriteString("?,")
+	}
+	buf.TruncateLast(1) // 移除末尾的逗号
+	buf.WriteByte(')')
+
+	// 构建 ON DUPLICATE KEY UPDATE 子句
+	if len(updates) > 0 {
+		buf.WriteString(" ON DUPLICATE KEY UPDATE ")
+		for i := 0; i < len(updates); i++ {
+			buf.WriteByte('`')
+			buf.WriteString(updates[i].Tag)
+			buf.WriteString("`=VALUES(`")
+			buf.WriteString(updates[i].Tag)
+			buf.WriteString("`),")
+		}
+		if autoInc != -1 {
+			buf.WriteByte('`')
+			buf.WriteString(qt.Elems[autoInc].Tag)
+			buf.WriteString("`=LAST_INSERT_ID(`")
+			buf.WriteString(qt.Elems[autoInc].Tag)
+			buf.WriteString("`),")
+		}
+		buf.TruncateLast(1) // 移除末尾的逗号
+	}
+
+	// 执行语句,忽略冲突时 LastInsertId 为 0,不回写
+	r, err := qt.conn.ExecContext(ctx, buf.String(), values...)
+	if err != nil {
+		return support.TranslateError(err)
+	}
+	if autoInc != -1 {
+		if lii, err := r.LastInsertId(); err == nil && lii != 0 {
+			_ = qt.Elems[autoInc].SetInt64(lii)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,203 @@
+// Copyright 2024 OblivionOcean
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package support
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/OblivionOcean/opao/utils"
+)
+
+// Named 命名参数的取值来源,保存在 Custom 条件的 Right 中,构建查询时展开为占位符
+type Named struct {
+	Arg any // 键为字符串的 map 或已注册模型的结构体(指针)
+}
+
+// BindNamed 将 query 中的 :name 与 @name 命名参数展开为占位符,返回 SQL 与按出现顺序排列的参数
+// arg 为 map 时按键取值,存在未使用的键时返回错误;为已注册模型的结构体(指针)时按字段的 db 标签取值
+// d 为 DialectPostgres 时展开为 $n,否则展开为 ?;引号内的内容、:: 类型转换与 @@ 系统变量原样保留,
+// d 为 DialectMySQL 时 @name 为用户变量,同样原样保留
+func (c *Config) BindNamed(d Dialect, query string, arg any) (string, []any, error) {
+	query, args, err := c.bindNamed(d, query, arg)
+	if err != nil {
+		return "", nil, err
+	}
+	return Rebind(d, query), args, nil
+}
+
+// bindNamed 与 BindNamed 相同,但总是展开为 ? 占位符,用于之后统一编号的条件
+func (c *Config) bindNamed(d Dialect, query string, arg any) (string, []any, error) {
+	lookup, names, err := c.namedLookup(arg)
+	if err != nil {
+		return "", nil, err
+	}
+	used := make(map[string]bool, len(names))
+	buf := utils.NewBuffer(len(query) + 8)
+	var args []any
+	for i := 0; i < len(query); i++ {
+		ch := query[i]
+		switch ch {
+		case '\'', '"', '`':
+			// 引号内的内容原样写入
+			end := QuotedEnd(query, i)
+			buf.WriteString(query[i : end+1])
+			i = end
+			continue
+		case ':', '@':
+			if i+1 < len(query) && query[i+1] == ch {
+				buf.WriteString(query[i : i+2])
+				i++
+				continue
+			}
+			if ch == '@' && d == DialectMySQL {
+				break
+			}
+			end := i + 1
+			for end < len(query) && isNameByte(query[end], end == i+1) {
+				end++
+			}
+			if end == i+1 {
+				break
+			}
+			name := query[i+1 : end]
+			val, ok := lookup(name)
+			if !ok {
+				return "", nil, errors.New("missing named parameter: " + name)
+			}
+			used[name] = true
+			args = append(args, val)
+			buf.WriteByte('?')
+			i = end - 1
+			continue
+		}
+		buf.WriteByte(ch)
+	}
+	var unused []string
+	for i := 0; i < len(names); i++ {
+		if !used[names[i]] {
+			unused = append(unused, names[i])
+		}
+	}
+	if len(unused) > 0 {
+		sort.Strings(unused)
+		return "", nil, errors.New("unused named parameters: " + strings.Join(unused, ", "))
+	}
+	return buf.String(), args, nil
+}
+
+// namedLookup 返回按名称取值的函数;arg 为 map 时同时返回所有键,用于检查未使用的参数
+func (c *Config) namedLookup(arg any) (func(name string) (any, bool), []string, error) {
+	val := reflect.ValueOf(arg)
+	if val.Kind() == reflect.Map {
+		if val.Type().Key().Kind() != reflect.String {
+			return nil, nil, errors.New("named parameter map must have string keys")
+		}
+		keys := val.MapKeys()
+		names := make([]string, len(keys))
+		for i := 0; i < len(keys); i++ {
+			names[i] = keys[i].String()
+		}
+		keyType := val.Type().Key()
+		return func(name string) (any, bool) {
+			v := val.MapIndex(reflect.ValueOf(name).Convert(keyType))
+			if !v.IsValid() {
+				return nil, false
+			}
+			return v.Interface(), true
+		}, names, nil
+	}
+
+	cache, err := c.Model(arg)
+	if err != nil {
+		return nil, nil, errors.New("named parameters require a map or a registered struct")
+	}
+	if val.Kind() != reflect.Pointer {
+		// 复制到可寻址的对象后读取字段
+		ptr := reflect.New(val.Type())
+		ptr.Elem().Set(val)
+		val = ptr
+	} else if val.IsNil() {
+		return nil, nil, errors.New("named parameter struct must not be nil")
+	}
+	elems := cache.Bind(val.UnsafePointer())
+	return func(name string) (any, bool) {
+		i := IndexElem(elems, name)
+		if i == -1 {
+			return nil, false
+		}
+		return elems[i].Get(), true
+	}, nil, nil
+}
+
+// Rebind 将 query 中的 ? 占位符展开为方言 d 的占位符,d 为 DialectPostgres 时依次替换为 $1、$2...,否则原样返回
+// 引号内的 ? 原样保留
+func Rebind(d Dialect, query string) string {
+	if d != DialectPostgres || strings.IndexByte(query, '?') == -1 {
+		return query
+	}
+	buf := utils.NewBuffer(len(query) + 8)
+	WriteRebind(&buf, query, 0)
+	return buf.String()
+}
+
+// WriteRebind 将 query 中的 ? 占位符替换为 PostgreSQL 的 $n 格式后写入 buf,引号内的 ? 原样保留
+// n 为语句中已使用的占位符数量,返回写入后的占位符数量
+func WriteRebind(buf *utils.Buffer, query string, n int) int {
+	for i := 0; i < len(query); i++ {
+		switch query[i] {
+		case '\'', '"', '`':
+			end := QuotedEnd(query, i)
+			buf.WriteString(query[i : end+1])
+			i = end
+		case '?':
+			n++
+			buf.WriteByte('$')
+			buf.WriteString(strconv.Itoa(n))
+		default:
+			buf.WriteByte(query[i])
+		}
+	}
+	return n
+}
+
+// QuotedEnd 返回从 query[start] 的引号开始的引号内容的结束位置,连续两个引号为转义
+// 引号未闭合时返回 len(query)-1
+func QuotedEnd(query string, start int) int {
+	ch := query[start]
+	end := start + 1
+	for end < len(query) {
+		if query[end] == ch {
+			if end+1 < len(query) && query[end+1] == ch {
+				end += 2
+				continue
+			}
+			return end
+		}
+		end++
+	}
+	return len(query) - 1
+}
+
+// isNameByte 判断 ch 能否作为命名参数名称的一部分,名称以字母或下划线开头
+func isNameByte(ch byte, first bool) bool {
+	if ch == '_' || (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z') {
+		return true
+	}
+	return !first && ch >= '0' && ch <= '9'
+}
@@ -0,0 +1,93 @@
+// Copyright 2024 OblivionOcean
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package support
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBindNamed(t *testing.T) {
+	type user struct {
+		Id   int64  `db:"id" option:"primaryKey;autoIncrement"`
+		Name string `db:"name"`
+	}
+	var orm ORM
+	orm.Init(nil, nil)
+	if err := orm.Register("user", &user{}); err != nil {
+		t.Fatal(err)
+	}
+	args := map[string]any{"a": 1, "b": "x"}
+	cases := []struct {
+		name    string
+		dialect Dialect
+		query   string
+		arg     any
+		sql     string
+		args    []any
+		err     string
+	}{
+		{"mysql", DialectMySQL, "a = :a AND b = :b AND c = :a", args, "a = ? AND b = ? AND c = ?", []any{1, "x", 1}, ""},
+		{"postgres", DialectPostgres, "a = :a AND b = @b", args, "a = $1 AND b = $2", []any{1, "x"}, ""},
+		{"sqlite", DialectSQLite, "a = @a AND b = :b", args, "a = ? AND b = ?", []any{1, "x"}, ""},
+		{"quoted", DialectPostgres, "a = :a AND b = ':b ?' AND \"c:b\" = :b", args, "a = $1 AND b = ':b ?' AND \"c:b\" = $2", []any{1, "x"}, ""},
+		{"escaped quote", DialectMySQL, "a = 'it''s :b' AND b = :b AND c = :a", args, "a = 'it''s :b' AND b = ? AND c = ?", []any{"x", 1}, ""},
+		{"cast", DialectPostgres, "a = :a::int AND b = :b", args, "a = $1::int AND b = $2", []any{1, "x"}, ""},
+		{"mysql variables", DialectMySQL, "a = :a AND b = :b AND @@sql_mode <> '' AND @c IS NULL", args, "a = ? AND b = ? AND @@sql_mode <> '' AND @c IS NULL", []any{1, "x"}, ""},
+		{"struct", DialectPostgres, "id = :id AND name = :name", &user{Id: 1, Name: "a"}, "id = $1 AND name = $2", []any{int64(1), "a"}, ""},
+		{"missing", DialectMySQL, "a = :a AND c = :c", args, "", nil, "missing named parameter: c"},
+		{"unused", DialectMySQL, "a = :a", map[string]any{"a": 1, "c": 2, "b": 3}, "", nil, "unused named parameters: b, c"},
+		{"unregistered", DialectMySQL, "a = :a", struct{ A int }{1}, "", nil, "named parameters require a map or a registered struct"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			sql, got, err := orm.Config().BindNamed(c.dialect, c.query, c.arg)
+			if c.err != "" {
+				if err == nil || err.Error() != c.err {
+					t.Fatalf("got %v, want %s", err, c.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if sql != c.sql {
+				t.Errorf("sql:\n got %s\nwant %s", sql, c.sql)
+			}
+			if !reflect.DeepEqual(got, c.args) {
+				t.Errorf("args: got %#v, want %#v", got, c.args)
+			}
+		})
+	}
+}
+
+func TestRebind(t *testing.T) {
+	cases := []struct {
+		dialect Dialect
+		query   string
+		want    string
+	}{
+		{DialectMySQL, "a = ? AND b = ?", "a = ? AND b = ?"},
+		{DialectSQLite, "a = ?", "a = ?"},
+		{DialectPostgres, "a = ? AND b = ?", "a = $1 AND b = $2"},
+		{DialectPostgres, "a = '?' AND \"b?\" = ? AND c = 'it''s ?' AND d = ?", "a = '?' AND \"b?\" = $1 AND c = 'it''s ?' AND d = $2"},
+		{DialectPostgres, "a = 'unterminated ?", "a = 'unterminated ?"},
+	}
+	for _, c := range cases {
+		if got := Rebind(c.dialect, c.query); got != c.want {
+			t.Errorf("Rebind(%d, %q):\n got %s\nwant %s", c.dialect, c.query, got, c.want)
+		}
+	}
+}
@@ -15,6 +15,7 @@
 package support
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"reflect"
@@ -26,10 +27,21 @@ import (
 	"github.com/OblivionOcean/opao/utils"
 )
 
+// Executor 执行 SQL 的连接,*sql.DB、*sql.Tx 与 *sql.Conn 均实现了该接口
+type Executor interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// Driver 各数据库方言的 ObjectORM 构造函数
+type Driver func(conn Executor, config *Config, obj any, objType reflect.Type, table string, elems []Elem, err error) ObjectORM
+
 type ORM struct {
-	objectORM func(*sql.DB, any, reflect.Type, string, []Elem, error) ObjectORM
+	objectORM Driver
 	caches    *SafeCache // map[reflect.Type]Cache
-	conn      *sql.DB
+	conn      Executor
+	config    *Config
 }
 
 type ObjectORM interface {
@@ -41,12 +53,118 @@ type ObjectORM interface {
 	Find(args ...any) (any, error)
 	FindAll(args ...any) ([]any, error)
 	Count(args ...any) (int, error)
+
+	CreateContext(ctx context.Context) error
+	UpdateContext(ctx context.Context, args ...any) error
+	SaveContext(ctx context.Context, args ...any) error
+	DeleteContext(ctx context.Context, args ...any) error
+	FindContext(ctx context.Context, args ...any) (any, error)
+	FindAllContext(ctx context.Context, args ...any) ([]any, error)
+	CountContext(ctx context.Context, args ...any) (int, error)
+
+	Sum(dest any, column string, args ...any) error
+	Avg(dest any, column string, args ...any) error
+	Min(dest any, column string, args ...any) error
+	Max(dest any, column string, args ...any) error
+	Aggregate(fn Aggregate, dest any, column string, args ...any) error
+	CountBy(groupColumn string, args ...any) (map[any]int64, error)
+	AggregateBy(fn Aggregate, column, groupColumn string, args ...any) ([]Group, error)
+	SumContext(ctx context.Context, dest any, column string, args ...any) error
+	AvgContext(ctx context.Context, dest any, column string, args ...any) error
+	MinContext(ctx context.Context, dest any, column string, args ...any) error
+	MaxContext(ctx context.Context, dest any, column string, args ...any) error
+	AggregateContext(ctx context.Context, fn Aggregate, dest any, column string, args ...any) error
+	CountByContext(ctx context.Context, groupColumn string, args ...any) (map[any]int64, error)
+	AggregateByContext(ctx context.Context, fn Aggregate, column, groupColumn string, args ...any) ([]Group, error)
+
+	CreateWithResult() (Result, error)
+	UpdateWithResult(args ...any) (Result, error)
+	SaveWithResult(args ...any) (Result, error)
+	DeleteWithResult(args ...any) (Result, error)
+	CreateWithResultContext(ctx context.Context) (Result, error)
+	UpdateWithResultContext(ctx context.Context, args ...any) (Result, error)
+	SaveWithResultContext(ctx context.Context, args ...any) (Result, error)
+	DeleteWithResultContext(ctx context.Context, args ...any) (Result, error)
+
+	UpdateColumns(values map[string]any, args ...any) error
+	UpdateColumnsContext(ctx context.Context, values map[string]any, args ...any) error
+	UpdateFields(fields ...string) error
+	UpdateFieldsContext(ctx context.Context, fields ...string) error
+
+	Each(fn func(obj any) error, args ...any) error
+	EachContext(ctx context.Context, fn func(obj any) error, args ...any) error
+	Reuse() ObjectORM
+
+	CreateBatch(objs any, batchSize int) error
+	CreateBatchContext(ctx context.Context, objs any, batchSize int) error
+
+	Upsert(conflictColumns ...string) error
+	UpsertContext(ctx context.Context, conflictColumns ...string) error
+	OnConflictDoNothing() ObjectORM
+	OnConflictUpdate(columns ...string) ObjectORM
+
+	FindByPK(ids ...any) (any, error)
+	FindByPKContext(ctx context.Context, ids ...any) (any, error)
+	DeleteByPK(ids ...any) error
+	DeleteByPKContext(ctx context.Context, ids ...any) error
+
+	UpdateReturning(args ...any) ([]any, error)
+	UpdateReturningContext(ctx context.Context, args ...any) ([]any, error)
+	DeleteReturning(args ...any) ([]any, error)
+	DeleteReturningContext(ctx context.Context, args ...any) ([]any, error)
+
+	AllowGlobal() ObjectORM
+	Select(columns ...string) ObjectORM
+	SelectExpr(exprs ...Expr) ObjectORM
+	Omit(columns ...string) ObjectORM
+	Distinct(columns ...string) ObjectORM
+	ForUpdate() ObjectORM
+	ForShare() ObjectORM
+	NoWait() ObjectORM
+	SkipLocked() ObjectORM
+
+	Pluck(column string, dest any, args ...any) error
+	PluckContext(ctx context.Context, column string, dest any, args ...any) error
+
+	Join(model any, on ...string) ObjectORM
+	LeftJoin(model any, on ...string) ObjectORM
+	InnerJoin(model any, on ...string) ObjectORM
+	Scan(dest any, args ...any) error
+	ScanContext(ctx context.Context, dest any, args ...any) error
+
+	With(name string, subquery any) ObjectORM
+	WithRecursive(name string, subquery any) ObjectORM
+	From(name string) ObjectORM
+
+	Union(queryParts ...any) ObjectORM
+	UnionAll(queryParts ...any) ObjectORM
+	Intersect(queryParts ...any) ObjectORM
+	Except(queryParts ...any) ObjectORM
+
+	Paginate(page, size int, args ...any) (Page, error)
+	PaginateContext(ctx context.Context, page, size int, args ...any) (Page, error)
+	CursorPaginate(cursor string, size int, args ...any) (CursorPage, error)
+	CursorPaginateContext(ctx context.Context, cursor string, size int, args ...any) (CursorPage, error)
 }
 
-func (orm *ORM) Init(conn *sql.DB, driver func(*sql.DB, any, reflect.Type, string, []Elem, error) ObjectORM) {
+func (orm *ORM) Init(conn Executor, driver Driver) {
 	orm.objectORM = driver
 	orm.conn = conn
 	orm.caches = &SafeCache{cache: map[reflect.Type]Cache{}}
+	orm.config = &Config{models: orm.caches}
+}
+
+// WithConn 返回使用 conn 执行 SQL 的 ORM 副本
+// 副本与原 ORM 共享注册信息与配置,用于在事务等连接上执行操作
+func (o *ORM) WithConn(conn Executor) ORM {
+	cp := *o
+	cp.conn = conn
+	return cp
+}
+
+// Config 返回该 ORM 的配置,修改会作用于所有由其加载的对象
+func (o *ORM) Config() *Config {
+	return o.config
 }
 
 func (o *ORM) Register(tableName string, object any) error {
@@ -106,6 +224,19 @@ func (o *ORM) Register(tableName string, object any) error {
 	return nil
 }
 
+// Registered 判断 object 的类型是否已注册,object 可以是结构体或结构体指针
+func (o *ORM) Registered(object any) bool {
+	objType := reflect.TypeOf(object)
+	if objType == nil {
+		return false
+	}
+	if objType.Kind() == reflect.Ptr {
+		objType = objType.Elem()
+	}
+	_, ok := o.caches.Load(objType)
+	return ok
+}
+
 func (o *ORM) Load(object any) (orm ObjectORM) {
 	objType := reflect.TypeOf(object)
 	objValue := reflect.ValueOf(object)
@@ -114,20 +245,22 @@ func (o *ORM) Load(object any) (orm ObjectORM) {
 		objType = ptr.Type()
 		objValue = ptr
 	} else {
-		orm = o.objectORM(nil, nil, nil, "", nil, errors.New("object must be a pointer to a struct"))
+		orm = o.objectORM(nil, nil, nil, nil, "", nil, errors.New("object must be a pointer to a struct"))
 		return
 	}
 	objPtr := objValue.UnsafeAddr()
 	if rawCache, ok := o.caches.Load(objType); ok {
 		cache := rawCache
+		// 缓存中的 Elems 被所有对象共享,需复制后再绑定字段指针
+		cache.Elems = append([]Elem(nil), rawCache.Elems...)
 		ElemsLength := len(cache.Elems)
 		for i := 0; i < ElemsLength; i++ {
 			cache.Elems[i].Ptr = unsafe.Pointer(objPtr + cache.Elems[i].Offset)
 		}
-		orm = o.objectORM(o.conn, object, cache.ObjType, cache.Table, cache.Elems, nil)
+		orm = o.objectORM(o.conn, o.config, object, cache.ObjType, cache.Table, cache.Elems, nil)
 		return
 	} else {
-		orm = o.objectORM(nil, nil, nil, "", nil, errors.New("object not registered"))
+		orm = o.objectORM(nil, nil, nil, nil, "", nil, errors.New("object not registered"))
 		return
 	}
 }
@@ -159,14 +292,14 @@ func (sc *SafeCache) Store(key reflect.Type, value Cache) {
 	sc.cache[key] = value
 }
 
+// WriteLii 将 LastInsertId 写入自增字段
 func WriteLii(elems []Elem, r sql.Result) {
 	elemsLeng := len(elems)
 	lii, liie := r.LastInsertId()
 	if liie == nil {
 		for i := 0; i < elemsLeng; i++ {
-			elem := elems[i]
-			if elem.Option["autoIncrement"] == "-" {
-				elems[i].Set(utils.AutotAnyI64tAnyI(lii))
+			if elems[i].Option["autoIncrement"] == "-" {
+				_ = elems[i].SetInt64(lii)
 			}
 		}
 	}
@@ -0,0 +1,36 @@
+// Copyright 2024 OblivionOcean
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package support
+
+// Page 分页查询的结果
+type Page struct {
+	Items []any // 当前页的行对象
+	Total int   // 满足条件的总行数
+	Page  int   // 当前页码,从 1 开始
+	Size  int   // 每页行数
+	Pages int   // 总页数
+}
+
+// NewPage 根据总行数计算分页信息
+func NewPage(items []any, total, page, size int) Page {
+	return Page{Items: items, Total: total, Page: page, Size: size, Pages: (total + size - 1) / size}
+}
+
+// CursorPage 游标分页查询的结果
+type CursorPage struct {
+	Items      []any  // 当前页的行对象
+	NextCursor string // 下一页的游标,没有下一页时为空
+	HasNext    bool   // 是否还有下一页
+}
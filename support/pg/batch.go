@@ -0,0 +1,156 @@
+// Copyright 2024 OblivionOcean
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pg
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+
+	"github.com/OblivionOcean/opao/support"
+	"github.com/OblivionOcean/opao/utils"
+)
+
+const (
+	maxParams = 65535 // 绑定参数数量上限
+)
+
+// CreateBatch 批量插入记录
+// objs 为已注册结构体或其指针的切片,按 batchSize 拆分为多条 INSERT ... VALUES (...),(...) 语句
+// batchSize <= 0 或超过绑定参数上限(65535)时按上限拆分
+// 自增字段通过 RETURNING 按插入顺序回写到每个元素
+// 多条语句之间不保证原子性,需要时请在事务中调用
+// 参数:
+//   - objs: 待插入的对象列表
+//   - batchSize: 每条语句插入的最大行数
+//
+// 返回:
+//   - error: 执行错误
+func (qt *PgSQL) CreateBatch(objs any, batchSize int) error {
+	return qt.CreateBatchContext(context.Background(), objs, batchSize)
+}
+
+// CreateBatchContext 与 CreateBatch 相同,使用 ctx 控制超时与取消
+func (qt *PgSQL) CreateBatchContext(ctx context.Context, objs any, batchSize int) error {
+	ctx, cancel := qt.config.Context(ctx)
+	defer cancel()
+
+	if qt.err != nil {
+		return qt.err
+	}
+	rows, err := support.BatchRows(objs, qt.objType)
+	if err != nil || len(rows) == 0 {
+		return err
+	}
+
+	// 区分插入字段与自增字段
+	cols := make([]support.Elem, 0, len(qt.Elems))
+	autoInc := -1
+	for i := 0; i < len(qt.Elems); i++ {
+		if qt.Elems[i].Option["autoIncrement"] == "-" {
+			autoInc = i
+			continue
+		}
+		cols = append(cols, qt.Elems[i])
+	}
+	if len(cols) == 0 {
+		return errors.New("no columns to insert")
+	}
+
+	// 计算每条语句的行数上限
+	maxRows := maxParams / len(cols)
+	if batchSize <= 0 || batchSize > maxRows {
+		batchSize = maxRows
+	}
+
+	// 构建 INSERT 语句头部
+	header := utils.NewBuffer(22 + len(qt.Table) + len(cols)*8)
+	header.WriteString("INSERT INTO \"")
+	header.WriteString(qt.Table)
+	header.WriteString("\" (")
+	for i := 0; i < len(cols); i++ {
+		header.WriteByte('"')
+		header.WriteString(cols[i].Tag)
+		header.WriteString("\",")
+	}
+	header.TruncateLast(1) // 移除末尾的逗号
+	header.WriteString(") VALUES ")
+
+	values := make([]any, 0, batchSize*len(cols))
+	for start := 0; start < len(rows); {
+		buf := utils.NewBuffer(len(header) + batchSize*(len(cols)*2+2))
+		buf.Write(header...)
+		values = values[:0]
+		end := start
+		for end < len(rows) && end-start < batchSize {
+			buf.WriteByte('(')
+			for i := 0; i < len(cols); i++ {
+				elem := cols[i].At(rows[end])
+				values = append(values, elem.Get())
+				buf.WriteByte('$')
+				buf.WriteString(strconv.Itoa(len(values)))
+				buf.WriteByte(',')
+			}
+			buf.TruncateLast(1) // 移除末尾的逗号
+			buf.WriteString("),")
+			end++
+		}
+		buf.TruncateLast(1) // 移除末尾的逗号
+
+		// 无自增字段时直接执行
+		if autoInc == -1 {
+			if _, err := qt.conn.ExecContext(ctx, buf.String(), values...); err != nil {
+				return support.TranslateError(err)
+			}
+			start = end
+			continue
+		}
+
+		// 通过 RETURNING 按插入顺序回写自增字段
+		buf.WriteString(" RETURNING \"")
+		buf.WriteString(qt.Elems[autoInc].Tag)
+		buf.WriteByte('"')
+		result, err := qt.conn.QueryContext(ctx, buf.String(), values...)
+		if err != nil {
+			return support.TranslateError(err)
+		}
+		n := start
+		for ; n < end && result.Next(); n++ {
+			elem := qt.Elems[autoInc].At(rows[n])
+			if err := result.Scan(elem.GetInterface()); err != nil {
+				_ = result.Close()
+				return support.TranslateError(err)
+			}
+		}
+		if err := closeRows(result); err != nil {
+			return err
+		}
+		if n != end {
+			return errors.New("RETURNING returned " + strconv.Itoa(n-start) + " rows, want " + strconv.Itoa(end-start))
+		}
+		start = end
+	}
+	return nil
+}
+
+// closeRows 关闭 rows 并返回遍历过程中的错误
+func closeRows(rows *sql.Rows) error {
+	err := rows.Err()
+	if closeErr := rows.Close(); err == nil {
+		err = closeErr
+	}
+	return support.TranslateError(err)
+}
@@ -16,30 +16,144 @@ package pg
 
 import (
 	"bytes"
+	"errors"
+	"reflect"
 	"unsafe"
 
 	"github.com/OblivionOcean/opao/support"
 	"github.com/OblivionOcean/opao/support/utils"
 )
 
-//go:inline
-func (qt *PgSQL) buildQuery(queryParts ...any) (string, []any) {
-	if len(queryParts) == 0 {
-		return "", nil
-	}
-	if len(queryParts) == 1 && queryParts[0] != nil {
-		if cond, ok := queryParts[0].(support.Condition); ok {
-			buf := &bytes.Buffer{}
-			buf.Grow(128)
-			args := make([]any, 0, utils.GetValueNum(cond))
-			args = qt.parseQuery(buf, args, cond)
-			bufByte := buf.Bytes()
-			return unsafe.String(&bufByte[0], len(bufByte)), args
-		} else {
-			return queryParts[0].(string), nil
-		}
-	}
-	return queryParts[0].(string), queryParts[1:]
+// buildQuery 解析查询条件
+// queryParts 可以是条件字符串加参数,参数末尾可以追加 GROUP BY、HAVING、ORDER BY、LIMIT、AFTER 条件;
+// 也可以是一个或多个 support.Condition,其中的 WHERE 条件以 AND 连接,子句条件按 SQL 顺序渲染在 WHERE 之后
+func (qt *PgSQL) buildQuery(queryParts ...any) (support.Query, error) {
+	var q support.Query
+	q.With, q.WithArgs = support.WithClause('"', qt.ctes)
+	if len(queryParts) == 0 || queryParts[0] == nil {
+		return q, qt.complete(&q)
+	}
+
+	// 条件字符串
+	if where, ok := queryParts[0].(string); ok {
+		args, clauses := support.SplitClauses(queryParts[1:])
+		q.Where, q.WhereArgs = where, args
+		for i := 0; i < len(clauses); i++ {
+			if err := qt.addClause(&q, clauses[i]); err != nil {
+				return q, err
+			}
+		}
+		return q, qt.complete(&q)
+	}
+
+	// 条件对象
+	conds := make([]support.Condition, 0, len(queryParts))
+	for i := 0; i < len(queryParts); i++ {
+		cond, ok := queryParts[i].(support.Condition)
+		if !ok {
+			return q, errors.New("query parts after a condition must also be conditions")
+		}
+		if cond.IsClause() {
+			if err := qt.addClause(&q, cond); err != nil {
+				return q, err
+			}
+			continue
+		}
+		cond, err := support.ResolveSubqueries(cond, qt.config, qt.subquery)
+		if err != nil {
+			return q, err
+		}
+		conds = append(conds, cond)
+	}
+	if len(conds) == 0 {
+		return q, qt.complete(&q)
+	}
+	buf := &bytes.Buffer{}
+	buf.Grow(128)
+	args := make([]any, 0, len(conds)*2)
+	for i := 0; i < len(conds); i++ {
+		if i > 0 {
+			buf.WriteString(" AND ")
+		}
+		args = qt.parseGroup(buf, args, conds[i], len(conds) > 1)
+	}
+	bufByte := buf.Bytes()
+	q.Where, q.WhereArgs = unsafe.String(&bufByte[0], len(bufByte)), args
+	return q, qt.complete(&q)
+}
+
+// complete 解析集合运算追加的 SELECT 并应用游标分页条件
+func (qt *PgSQL) complete(q *support.Query) error {
+	err := support.ResolveSets(q, qt.sets, func(parts []any) (support.Query, error) {
+		cp := *qt
+		cp.ctes, cp.sets = nil, nil // WITH 子句只渲染在整个语句之前
+		return cp.buildQuery(parts...)
+	})
+	if err != nil {
+		return err
+	}
+	return support.ApplyCursor(q, qt.Elems, '"')
+}
+
+// addClause 将子句条件写入 q,HAVING 条件按 WHERE 条件的规则解析
+func (qt *PgSQL) addClause(q *support.Query, cond support.Condition) error {
+	if cond.Type != support.HAVING {
+		return support.AddClause(q, qt.clauseElems(), '"', cond)
+	}
+	having, ok := cond.Left.(support.Condition)
+	if !ok || having.IsClause() {
+		return errors.New("HAVING requires a condition")
+	}
+	having, err := support.ResolveSubqueries(having, qt.config, qt.subquery)
+	if err != nil {
+		return err
+	}
+	buf := &bytes.Buffer{}
+	if q.Having != "" {
+		buf.WriteString(q.Having)
+		buf.WriteString(" AND ")
+	}
+	q.HavingArgs = qt.parseGroup(buf, q.HavingArgs, having, q.Having != "")
+	q.Having = buf.String()
+	return nil
+}
+
+// subquery 渲染由其他模型构建的子查询,使用 ? 占位符
+// 子查询的条件按子查询模型的字段解析,参数按出现顺序返回,由外层查询依次合并
+func (qt *PgSQL) subquery(sub support.Subquery) (string, []any, error) {
+	cache, err := qt.config.Model(sub.Model)
+	if err != nil {
+		return "", nil, err
+	}
+	obj := reflect.New(cache.ObjType)
+	sq := &PgSQL{
+		Table:   cache.Table,
+		Elems:   cache.Bind(obj.UnsafePointer()),
+		conn:    qt.conn,
+		config:  qt.config,
+		obj:     obj.Interface(),
+		objType: cache.ObjType,
+	}
+	q, err := sq.buildQuery(sub.Parts...)
+	if err != nil {
+		return "", nil, err
+	}
+	sqlStr, err := support.SubquerySQL(sq.tables(), sq.Elems, '"', sub.Column, &q)
+	if err != nil {
+		return "", nil, err
+	}
+	return sqlStr, q.Args(), nil
+}
+
+// parseGroup 解析条件,wrap 为 true 时为 AND/OR 组合条件加括号,保证与相邻条件组合时的优先级
+func (qt *PgSQL) parseGroup(buf *bytes.Buffer, args []any, cond support.Condition, wrap bool) []any {
+	if !wrap || (cond.Type != support.AND && cond.Type != support.OR) {
+		return qt.parseQuery(buf, args, cond)
+	}
+	buf.WriteByte('(')
+	args = qt.parseQuery(buf, args, cond)
+	buf.WriteByte(')')
+	return args
 }
 
 func (qt *PgSQL) parseQuery(buf *bytes.Buffer, args []any, cond support.Condition) []any {
@@ -54,7 +168,7 @@ func (qt *PgSQL) parseQuery(buf *bytes.Buffer, args []any, cond support.Conditio
 				buf.WriteString(" = ?")
 				args = append(args, arg)
 			} else if condition, ok := arg.(support.Condition); ok {
-				args = qt.parseQuery(buf, args, condition)
+				args = qt.parseGroup(buf, args, condition, true)
 
 			}
 			if i < len(cond.Args)-1 {
@@ -70,7 +184,7 @@ func (qt *PgSQL) parseQuery(buf *bytes.Buffer, args []any, cond support.Conditio
 				buf.WriteString(" = ?")
 				args = append(args, arg)
 			} else if condition, ok := arg.(support.Condition); ok {
-				args = qt.parseQuery(buf, args, condition)
+				args = qt.parseGroup(buf, args, condition, true)
 			}
 			if i < len(cond.Args)-1 {
 				buf.WriteString(" OR ")
@@ -141,8 +255,8 @@ func (qt *PgSQL) parseQuery(buf *bytes.Buffer, args []any, cond support.Conditio
 		}
 		args = append(args, right)
 	case support.BETWEEN, support.NOT_BETWEEN:
-		if len(cond.Args) != 3 {
-			panic("BETWEEN condition must have exactly 3 arguments")
+		if cond.Left == nil || len(cond.Args) != 2 {
+			panic("BETWEEN condition must have a field and exactly 2 values")
 		}
 		left := cond.Left.(string)
 		start := cond.Args[0]
@@ -172,6 +286,7 @@ func (qt *PgSQL) parseQuery(buf *bytes.Buffer, args []any, cond support.Conditio
 		default:
 			panic("UNKNOWN EXISTS CONDITION TYPE")
 		}
+		args = append(args, cond.Args...)
 	case support.IN_SUBQUERY, support.NOT_IN_SUBQUERY:
 		if cond.Left == nil || cond.Right == nil {
 			panic("IN_SUBQUERY condition must have exactly 2 arguments")
@@ -188,6 +303,7 @@ func (qt *PgSQL) parseQuery(buf *bytes.Buffer, args []any, cond support.Conditio
 		default:
 			panic("UNKNOWN IN_SUBQUERY CONDITION TYPE")
 		}
+		args = append(args, cond.Args...)
 	case support.IN_VALUES, support.NOT_IN_VALUES:
 		if cond.Left == nil || len(cond.Args) == 0 {
 			panic("IN_VALUES condition must have at least 2 arguments")
@@ -212,24 +328,11 @@ func (qt *PgSQL) parseQuery(buf *bytes.Buffer, args []any, cond support.Conditio
 			args = append(args, value)
 		}
 		buf.WriteString(")")
-	case support.LIMIT:
-		if cond.Left == nil {
-			return args
-		}
-		if cond.Right == nil && cond.Left != nil {
-			buf.WriteString(" LIMIT ?")
-			args = append(args, cond.Left)
-		} else if cond.Left != nil && cond.Right != nil {
-			buf.WriteString(" LIMIT ?, ?")
-			args = append(args, cond.Left, cond.Right)
-		} else {
-			panic("LIMIT condition must have 1 or 2 arguments")
-		}
+	case support.LIMIT, support.ORDER_BY, support.GROUP_BY, support.HAVING:
+		panic("LIMIT, ORDER BY, GROUP BY and HAVING must be top-level conditions")
 	case support.CUSTOM:
-		buf.WriteString(cond.Args[0].(string))
-		if len(cond.Args) > 1 {
-			args = append(args, cond.Args[1:]...)
-		}
+		buf.WriteString(cond.Left.(string))
+		args = append(args, cond.Args...)
 	default:
 		panic("UNKNOWN CONDITION TYPE")
 	}
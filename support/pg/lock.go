@@ -0,0 +1,51 @@
+// Copyright 2024 OblivionOcean
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pg
+
+import "github.com/OblivionOcean/opao/support"
+
+// ForUpdate 返回查询时加排他行锁的 ObjectORM 副本,渲染为 LIMIT 之后的 FOR UPDATE
+// 行锁作用于 Find、FindAll、Each、Pluck 等返回行的查询,不作用于 Count 与聚合查询
+// 只能在 Database.Transaction 开启的事务中使用,否则通过 Error 返回 ErrLockOutsideTx,参见 support.Lock.With
+func (qt *PgSQL) ForUpdate() support.ObjectORM {
+	return qt.withLock(support.LockUpdate, "")
+}
+
+// ForShare 返回查询时加共享行锁的 ObjectORM 副本,渲染为 FOR SHARE
+func (qt *PgSQL) ForShare() support.ObjectORM {
+	return qt.withLock(support.LockShare, "")
+}
+
+// NoWait 返回行已被锁定时立即报错而不等待的 ObjectORM 副本,需要先调用 ForUpdate 或 ForShare
+func (qt *PgSQL) NoWait() support.ObjectORM {
+	return qt.withLock("", support.LockNoWait)
+}
+
+// SkipLocked 返回跳过已被锁定行的 ObjectORM 副本,需要先调用 ForUpdate 或 ForShare
+func (qt *PgSQL) SkipLocked() support.ObjectORM {
+	return qt.withLock("", support.LockSkipLocked)
+}
+
+// withLock 返回设置了行锁的副本,错误通过 Error 返回
+func (qt *PgSQL) withLock(mode, wait string) support.ObjectORM {
+	cp := *qt
+	lock, err := qt.lock.With(qt.conn, mode, wait)
+	if err != nil {
+		cp.err = err
+		return &cp
+	}
+	cp.lock = lock
+	return &cp
+}
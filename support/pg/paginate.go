@@ -0,0 +1,132 @@
+// Copyright 2024 OblivionOcean
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pg
+
+import (
+	"context"
+	"errors"
+
+	"github.com/OblivionOcean/opao/support"
+)
+
+// Paginate 分页查询,返回第 page 页的行对象、总行数与分页信息
+// 渲染为 LIMIT ? OFFSET ?,queryParts 中不能包含 LIMIT;未指定 ORDER BY 时各页的顺序不确定
+// 参数:
+//   - page: 页码,从 1 开始,小于 1 时按 1 处理
+//   - size: 每页行数,必须大于 0
+//   - queryParts: 查询条件部分,可以是条件字符串和参数
+//
+// 返回:
+//   - support.Page: 分页结果
+//   - error: 执行错误
+func (qt *PgSQL) Paginate(page, size int, queryParts ...any) (support.Page, error) {
+	return qt.PaginateContext(context.Background(), page, size, queryParts...)
+}
+
+// PaginateContext 与 Paginate 相同,使用 ctx 控制超时与取消
+func (qt *PgSQL) PaginateContext(ctx context.Context, page, size int, queryParts ...any) (support.Page, error) {
+	ctx, cancel := qt.config.Context(ctx)
+	defer cancel()
+
+	if qt.err != nil {
+		return support.Page{}, qt.err
+	}
+	if size <= 0 {
+		return support.Page{}, errors.New("page size must be positive")
+	}
+	if page < 1 {
+		page = 1
+	}
+	q, err := qt.buildQuery(queryParts...)
+	if err != nil {
+		return support.Page{}, err
+	}
+	if q.Limit != "" {
+		return support.Page{}, errors.New("paginate does not accept LIMIT conditions")
+	}
+
+	// 统计总行数,超出最后一页时不再查询
+	total, err := qt.count(ctx, q)
+	if err != nil {
+		return support.Page{}, err
+	}
+	offset := (page - 1) * size
+	if offset >= total {
+		return support.NewPage(nil, total, page, size), nil
+	}
+
+	if err = support.AddClause(&q, qt.Elems, '"', support.Condition{Type: support.LIMIT, Left: size, Right: offset}); err != nil {
+		return support.Page{}, err
+	}
+	items, err := qt.findAll(ctx, &q)
+	if err != nil {
+		return support.Page{}, err
+	}
+	return support.NewPage(items, total, page, size), nil
+}
+
+// CursorPaginate 游标分页查询,返回 cursor 之后的至多 size 行
+// queryParts 中必须包含 ORDER BY,且排序字段组合必须唯一(通常以主键结尾),不能包含 LIMIT 与 AFTER;
+// 首页传入空游标,之后传入上一页返回的 NextCursor。游标按排序字段取值,不使用 OFFSET,深分页时依然可以使用索引
+// 参数:
+//   - cursor: 上一页返回的游标,首页为空
+//   - size: 每页行数,必须大于 0
+//   - queryParts: 查询条件部分,可以是条件字符串和参数
+//
+// 返回:
+//   - support.CursorPage: 分页结果
+//   - error: 执行错误
+func (qt *PgSQL) CursorPaginate(cursor string, size int, queryParts ...any) (support.CursorPage, error) {
+	return qt.CursorPaginateContext(context.Background(), cursor, size, queryParts...)
+}
+
+// CursorPaginateContext 与 CursorPaginate 相同,使用 ctx 控制超时与取消
+func (qt *PgSQL) CursorPaginateContext(ctx context.Context, cursor string, size int, queryParts ...any) (support.CursorPage, error) {
+	ctx, cancel := qt.config.Context(ctx)
+	defer cancel()
+
+	if qt.err != nil {
+		return support.CursorPage{}, qt.err
+	}
+	if size <= 0 {
+		return support.CursorPage{}, errors.New("page size must be positive")
+	}
+	after := support.Condition{Type: support.AFTER, Left: cursor}
+	q, err := qt.buildQuery(append(queryParts[:len(queryParts):len(queryParts)], after)...)
+	if err != nil {
+		return support.CursorPage{}, err
+	}
+	if len(q.Order) == 0 {
+		return support.CursorPage{}, errors.New("cursor pagination requires ORDER BY")
+	}
+	if q.Limit != "" {
+		return support.CursorPage{}, errors.New("cursor pagination does not accept LIMIT conditions")
+	}
+
+	// 多取一行用于判断是否还有下一页
+	if err = support.AddClause(&q, qt.Elems, '"', support.Condition{Type: support.LIMIT, Left: size + 1}); err != nil {
+		return support.CursorPage{}, err
+	}
+	items, err := qt.findAll(ctx, &q)
+	if err != nil {
+		return support.CursorPage{}, err
+	}
+	page := support.CursorPage{Items: items}
+	if len(items) > size {
+		page.Items, page.HasNext = items[:size], true
+		page.NextCursor, err = support.EncodeCursor(qt.columns(), q.Order, items[size-1])
+	}
+	return page, err
+}
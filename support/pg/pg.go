@@ -15,11 +15,12 @@
 package pg
 
 import (
+	"context"
 	"database/sql"
 	"database/sql/driver"
-	"fmt"
+	"errors"
 	"reflect"
-	"strings"
+	"strconv"
 
 	"github.com/OblivionOcean/opao/support"
 	"github.com/OblivionOcean/opao/utils"
@@ -27,27 +28,41 @@ import (
 
 // PgSQL PostgreSQL 数据库 ORM 实现
 type PgSQL struct {
-	Table   string         // 表名
-	err     error          // 错误信息
-	Elems   []support.Elem // 字段元素列表
-	conn    *sql.DB        // 数据库连接
-	obj     any            // 关联的对象
-	objType reflect.Type   // 对象类型
+	Table    string                 // 表名
+	err      error                  // 错误信息
+	Elems    []support.Elem         // 字段元素列表
+	conn     support.Executor       // 数据库连接或事务
+	config   *support.Config        // ORM 配置
+	obj      any                    // 关联的对象
+	objType  reflect.Type           // 对象类型
+	reuse    bool                   // Each 遍历时复用行对象
+	conflict support.Conflict       // Upsert 冲突处理方式
+	global   bool                   // 允许没有条件的全表写入
+	fields   []support.Elem         // Select/Omit 限定的字段,为 nil 时使用全部字段
+	selected bool                   // 字段由 Select 显式指定,Update/Create 时写入零值
+	distinct bool                   // 查询时使用 SELECT DISTINCT
+	joins    []support.Join         // JOIN 连接的表
+	ctes     []support.CTE          // With/WithRecursive 定义的公用表表达式
+	sets     []support.SetOperation // UNION 等集合运算追加的 SELECT
+	from     string                 // From 指定的查询来源,为空时使用表名
+	exprs    []support.Expr         // SelectExpr 指定的字段表达式,已按方言渲染
+	lock     support.Lock           // 查询的行锁子句
 }
 
 // NewPg 创建 PostgreSQL ORM 实例
 // 参数:
 //   - conn: 数据库连接
+//   - config: ORM 配置
 //   - obj: 关联的对象
 //   - objType: 对象的类型信息
 //   - table: 表名
 //   - Elems: 字段元素列表
 //   - err: 初始化错误
-func NewPg(conn *sql.DB, obj any, objType reflect.Type, table string, Elems []support.Elem, err error) support.ObjectORM {
+func NewPg(conn support.Executor, config *support.Config, obj any, objType reflect.Type, table string, Elems []support.Elem, err error) support.ObjectORM {
 	if err != nil {
 		return &PgSQL{err: err}
 	}
-	return &PgSQL{Table: table, Elems: Elems, err: err, conn: conn, obj: obj, objType: objType}
+	return &PgSQL{Table: table, Elems: Elems, err: err, conn: conn, config: config, obj: obj, objType: objType}
 }
 
 // Error 返回当前 ORM 实例的错误信息
@@ -55,6 +70,14 @@ func (qt *PgSQL) Error() error {
 	return qt.err
 }
 
+// AllowGlobal 返回允许全表写入的 ObjectORM 副本
+// 默认情况下没有条件(且无法按主键匹配)的 Update/Save/Delete 返回 support.ErrMissingWhere
+func (qt *PgSQL) AllowGlobal() support.ObjectORM {
+	cp := *qt
+	cp.global = true
+	return &cp
+}
+
 // Update 更新数据库记录
 // 根据提供的查询条件和值更新记录,仅更新非零值且非自增字段的字段
 // 参数:
@@ -63,82 +86,46 @@ func (qt *PgSQL) Error() error {
 // 返回:
 //   - error: 执行错误
 func (qt *PgSQL) Update(queryParts ...any) error {
-	query, args := qt.buildQuery(queryParts...)
-	// 收集需要更新的字段值(非零值且非自增字段)
-	values := make([]any, 0, len(qt.Elems))
-	for i := 0; i < len(qt.Elems); i++ {
-		if qt.Elems[i].Zero() {
-			continue
-		}
-		if qt.Elems[i].Option["autoIncrement"] == "-" {
-			continue
-		}
-		values = append(values, qt.Elems[i].Get())
-	}
-	values = append(values, args...)
+	return qt.UpdateContext(context.Background(), queryParts...)
+}
 
-	// 计算 SQL 语句所需的缓冲区大小
-	elemsLeng := len(qt.Elems)
-	tabNameLen := len(qt.Table)
-	queryStringLen := len(query)
-	elemsNameLength := 0
-	for i := 0; i < elemsLeng; i++ {
-		if qt.Elems[i].Zero() {
-			continue
-		}
-		if qt.Elems[i].Option["autoIncrement"] == "-" {
-			continue
-		}
-		// 字段名(2个引号) + "=$n"(4) + 逗号(1)
-		elemsNameLength += len(qt.Elems[i].Tag) + 4 + 1
-	}
+// UpdateContext 与 Update 相同,使用 ctx 控制超时与取消
+func (qt *PgSQL) UpdateContext(ctx context.Context, queryParts ...any) error {
+	_, err := qt.UpdateWithResultContext(ctx, queryParts...)
+	return err
+}
 
-	// 创建缓冲区并构建 UPDATE 语句
-	var buf utils.Buffer
-	if query == "" {
-		buf = utils.NewBuffer(15 + tabNameLen + elemsNameLength) // UPDATE "table" SET
-	} else {
-		buf = utils.NewBuffer(21 + tabNameLen + queryStringLen + elemsNameLength) // UPDATE "table" SET WHERE
-	}
-	buf.WriteString("UPDATE \"")
-	buf.WriteString(qt.Table)
-	buf.WriteString("\" SET ")
+// UpdateWithResult 与 Update 相同,同时返回受影响的行数与执行的 SQL
+func (qt *PgSQL) UpdateWithResult(queryParts ...any) (support.Result, error) {
+	return qt.UpdateWithResultContext(context.Background(), queryParts...)
+}
 
-	// 构建 SET 子句,使用 PostgreSQL 的 $n 占位符
-	whereCounter := 1
-	for i := 0; i < len(qt.Elems); i++ {
-		if qt.Elems[i].Option["autoIncrement"] == "-" {
-			continue
-		}
-		whereCounter++
-		buf.WriteString(fmt.Sprintf("\"=$%d", whereCounter))
-		buf.WriteByte(',')
+// UpdateWithResultContext 与 UpdateWithResult 相同,使用 ctx 控制超时与取消
+func (qt *PgSQL) UpdateWithResultContext(ctx context.Context, queryParts ...any) (support.Result, error) {
+	ctx, cancel := qt.config.Context(ctx)
+	defer cancel()
+
+	queryParts, sets := support.SplitAssignments(queryParts)
+	query, args, err := qt.writeQuery(queryParts...)
+	if err != nil {
+		return support.Result{}, err
+	}
+	sqlStr, values, err := qt.buildUpdate(query, args, sets, false)
+	if err != nil {
+		return support.Result{}, err
 	}
-	buf.TruncateLast(1) // 移除末尾的逗号
 
-	// 构建 WHERE 子句
-	if query != "" {
-		buf.WriteString(" WHERE ")
-		// 替换问号为 PostgreSQL 占位符格式($n)
-		var processedQuery strings.Builder
-		for _, c := range query {
-			if c == '?' {
-				processedQuery.WriteString(fmt.Sprintf("$%d", whereCounter))
-				whereCounter++
-			} else {
-				processedQuery.WriteRune(c)
-			}
-		}
-		buf.WriteString(processedQuery.String())
+	// 如果没有字段需要更新,直接返回
+	if sqlStr == "" {
+		return support.Result{}, nil
 	}
 
 	// 执行 UPDATE 语句
-	r, err := qt.conn.Exec(buf.String(), values...)
+	r, err := qt.conn.ExecContext(ctx, sqlStr, values...)
 	if err != nil {
-		return err
+		return support.Result{}, support.TranslateError(err)
 	}
-	support.WriteLii(qt.Elems, r)
-	return err
+	return support.NewResult(r, sqlStr), nil
 }
 
 // Save 保存或更新数据库记录
@@ -149,76 +136,44 @@ func (qt *PgSQL) Update(queryParts ...any) error {
 // 返回:
 //   - error: 执行错误
 func (qt *PgSQL) Save(queryParts ...any) error {
-	query, args := qt.buildQuery(queryParts...)
-	// 收集所有字段值(非自增字段)
-	values := make([]any, 0, len(qt.Elems))
-	for i := 0; i < len(qt.Elems); i++ {
-		if qt.Elems[i].Option["autoIncrement"] == "-" {
-			continue
-		}
-		values = append(values, qt.Elems[i].Get())
-	}
-	values = append(values, args...)
+	return qt.SaveContext(context.Background(), queryParts...)
+}
 
-	// 计算 SQL 语句所需的缓冲区大小
-	elemsLeng := len(qt.Elems)
-	tabNameLen := len(qt.Table)
-	queryStringLen := len(query)
-	elemsNameLength := 0
-	for i := 0; i < elemsLeng; i++ {
-		if qt.Elems[i].Option["autoIncrement"] == "-" {
-			continue
-		}
-		// 字段名(2个引号) + "=$n"(4) + 逗号(1)
-		elemsNameLength += len(qt.Elems[i].Tag) + 4 + 1
-	}
+// SaveContext 与 Save 相同,使用 ctx 控制超时与取消
+func (qt *PgSQL) SaveContext(ctx context.Context, queryParts ...any) error {
+	_, err := qt.SaveWithResultContext(ctx, queryParts...)
+	return err
+}
 
-	// 创建缓冲区并构建 UPDATE 语句
-	var buf utils.Buffer
-	if query == "" {
-		buf = utils.NewBuffer(15 + tabNameLen + elemsNameLength)
-	} else {
-		buf = utils.NewBuffer(21 + tabNameLen + queryStringLen + elemsNameLength)
-	}
-	buf.WriteString("UPDATE \"")
-	buf.WriteString(qt.Table)
-	buf.WriteString("\" SET ")
+// SaveWithResult 与 Save 相同,同时返回受影响的行数与执行的 SQL
+func (qt *PgSQL) SaveWithResult(queryParts ...any) (support.Result, error) {
+	return qt.SaveWithResultContext(context.Background(), queryParts...)
+}
 
-	// 构建 SET 子句,使用 PostgreSQL 的 $n 占位符
-	whereCounter := 1
-	for i := 0; i < len(qt.Elems); i++ {
-		if qt.Elems[i].Option["autoIncrement"] == "-" {
-			continue
-		}
-		whereCounter++
-		buf.WriteString(fmt.Sprintf("\"=$%d", whereCounter))
-		buf.WriteByte(',')
-	}
-	buf.TruncateLast(1) // 移除末尾的逗号
+// SaveWithResultContext 与 SaveWithResult 相同,使用 ctx 控制超时与取消
+func (qt *PgSQL) SaveWithResultContext(ctx context.Context, queryParts ...any) (support.Result, error) {
+	ctx, cancel := qt.config.Context(ctx)
+	defer cancel()
 
-	// 构建 WHERE 子句
-	if query != "" {
-		buf.WriteString(" WHERE ")
-		// 替换问号为 PostgreSQL 占位符格式($n)
-		var processedQuery strings.Builder
-		for _, c := range query {
-			if c == '?' {
-				processedQuery.WriteString(fmt.Sprintf("$%d", whereCounter))
-				whereCounter++
-			} else {
-				processedQuery.WriteRune(c)
-			}
-		}
-		buf.WriteString(processedQuery.String())
+	queryParts, sets := support.SplitAssignments(queryParts)
+	query, args, err := qt.writeQuery(queryParts...)
+	if err != nil {
+		return support.Result{}, err
+	}
+	sqlStr, values, err := qt.buildUpdate(query, args, sets, true)
+	if err != nil {
+		return support.Result{}, err
+	}
+	if sqlStr == "" {
+		return support.Result{}, nil
 	}
 
 	// 执行 UPDATE 语句
-	r, err := qt.conn.Exec(buf.String(), values...)
+	r, err := qt.conn.ExecContext(ctx, sqlStr, values...)
 	if err != nil {
-		return err
+		return support.Result{}, support.TranslateError(err)
 	}
-	support.WriteLii(qt.Elems, r)
-	return err
+	return support.NewResult(r, sqlStr), nil
 }
 
 // Delete 删除数据库记录
@@ -229,97 +184,89 @@ func (qt *PgSQL) Save(queryParts ...any) error {
 // 返回:
 //   - error: 执行错误
 func (qt *PgSQL) Delete(queryParts ...any) error {
-	query, args := qt.buildQuery(queryParts...)
+	return qt.DeleteContext(context.Background(), queryParts...)
+}
 
-	// 计算 SQL 语句所需的缓冲区大小
-	tabNameLen := len(qt.Table)
-	queryStringLen := len(query)
-	var buf utils.Buffer
-	if query == "" {
-		buf = utils.NewBuffer(14 + tabNameLen) // DELETE FROM "table"
-	} else {
-		buf = utils.NewBuffer(20 + tabNameLen + queryStringLen) // DELETE FROM "table" WHERE
-	}
-	buf.WriteString("DELETE FROM \"")
-	buf.WriteString(qt.Table)
-	buf.WriteByte('"')
+// DeleteContext 与 Delete 相同,使用 ctx 控制超时与取消
+func (qt *PgSQL) DeleteContext(ctx context.Context, queryParts ...any) error {
+	_, err := qt.DeleteWithResultContext(ctx, queryParts...)
+	return err
+}
 
-	// 构建 WHERE 子句
-	if query != "" {
-		buf.WriteString(" WHERE ")
-		// 替换问号为 PostgreSQL 占位符格式($n)
-		whereCounter := 1
-		var processedQuery strings.Builder
-		for _, c := range query {
-			if c == '?' {
-				processedQuery.WriteString(fmt.Sprintf("$%d", whereCounter))
-				whereCounter++
-			} else {
-				processedQuery.WriteRune(c)
-			}
-		}
-		buf.WriteString(processedQuery.String())
+// DeleteWithResult 与 Delete 相同,同时返回受影响的行数与执行的 SQL
+func (qt *PgSQL) DeleteWithResult(queryParts ...any) (support.Result, error) {
+	return qt.DeleteWithResultContext(context.Background(), queryParts...)
+}
+
+// DeleteWithResultContext 与 DeleteWithResult 相同,使用 ctx 控制超时与取消
+func (qt *PgSQL) DeleteWithResultContext(ctx context.Context, queryParts ...any) (support.Result, error) {
+	ctx, cancel := qt.config.Context(ctx)
+	defer cancel()
+
+	query, args, err := qt.writeQuery(queryParts...)
+	if err != nil {
+		return support.Result{}, err
 	}
 
 	// 执行 DELETE 语句
-	_, err := qt.conn.Exec(buf.String(), args...)
-	return err
+	sqlStr, args := qt.buildDelete(query, args)
+	r, err := qt.conn.ExecContext(ctx, sqlStr, args...)
+	if err != nil {
+		return support.Result{}, support.TranslateError(err)
+	}
+	return support.NewResult(r, sqlStr), nil
 }
 
 // Create 插入新记录到数据库
-// 使用 INSERT 语句将数据插入到表中,跳过自增字段
+// 使用 INSERT 语句将数据插入到表中,跳过自增字段与值为零的默认值字段
+// 自增字段与数据库生成的默认值通过 RETURNING 写回对象
 // 返回:
 //   - error: 执行错误
 func (qt *PgSQL) Create() error {
-	elemsLeng := len(qt.Elems)
-	tabNameLen := len(qt.Table)
+	return qt.CreateContext(context.Background())
+}
 
-	// 计算 SQL 语句所需的缓冲区大小
-	elemsNameLength := 0
-	for i := 0; i < elemsLeng; i++ {
-		if qt.Elems[i].Option["autoIncrement"] == "-" {
-			continue
-		}
-		// 字段名(2个引号) + 逗号(2)
-		elemsNameLength += len(qt.Elems[i].Tag) + 3 + 2
-	}
+// CreateContext 与 Create 相同,使用 ctx 控制超时与取消
+func (qt *PgSQL) CreateContext(ctx context.Context) error {
+	_, err := qt.CreateWithResultContext(ctx)
+	return err
+}
 
-	// 创建缓冲区并构建 INSERT 语句
-	buf := utils.NewBuffer(29 + tabNameLen + elemsNameLength) // INSERT INTO "table" (...) VALUES (...);
-	buf.WriteString("INSERT INTO \"")
-	buf.WriteString(qt.Table)
-	buf.WriteString("\" (")
+// CreateWithResult 与 Create 相同,同时返回受影响的行数、自增 ID 与执行的 SQL
+func (qt *PgSQL) CreateWithResult() (support.Result, error) {
+	return qt.CreateWithResultContext(context.Background())
+}
 
-	// 构建字段列表
-	values := make([]any, 0, elemsLeng)
-	for i := 0; i < elemsLeng; i++ {
-		buf.WriteByte('"')
-		buf.WriteString(qt.Elems[i].Tag)
-		buf.WriteByte('"')
-		values = append(values, qt.Elems[i].Get())
-		buf.WriteByte(',')
+// CreateWithResultContext 与 CreateWithResult 相同,使用 ctx 控制超时与取消
+func (qt *PgSQL) CreateWithResultContext(ctx context.Context) (support.Result, error) {
+	ctx, cancel := qt.config.Context(ctx)
+	defer cancel()
+
+	if qt.err != nil {
+		return support.Result{}, qt.err
 	}
-	buf.TruncateLast(1) // 移除末尾的逗号
-	buf.WriteString(") VALUES (")
 
-	// 构建 VALUES 子句,使用 PostgreSQL 的 $n 占位符
-	for i := 0; i < elemsLeng; i++ {
-		if qt.Elems[i].Option["autoIncrement"] == "-" {
-			continue
+	inserts, generated := support.InsertColumns(qt.Elems, qt.columns(), qt.selected)
+	sqlStr, values := qt.buildInsert(inserts)
+
+	// 没有需要回填的字段时直接执行
+	if len(generated) == 0 {
+		r, err := qt.conn.ExecContext(ctx, sqlStr, values...)
+		if err != nil {
+			return support.Result{}, support.TranslateError(err)
 		}
-		buf.WriteString(fmt.Sprintf("$%d", i+1))
-		buf.WriteByte(',')
+		return support.NewResult(r, sqlStr), nil
 	}
-	buf.TruncateLast(1) // 移除末尾的逗号
-	buf.WriteString(");")
 
-	// 执行 INSERT 语句
-	r, err := qt.conn.Exec(buf.String(), values...)
-	if err != nil {
-		return err
+	// 通过 RETURNING 回填数据库生成的字段
+	buf := utils.NewBuffer(len(sqlStr) + 11 + len(generated)*8)
+	buf.WriteString(sqlStr)
+	Scans := writeReturning(&buf, generated)
+	sqlStr = buf.String()
+	if err := qt.conn.QueryRowContext(ctx, sqlStr, values...).Scan(Scans...); err != nil {
+		return support.Result{}, support.TranslateError(err)
 	}
-	support.WriteLii(qt.Elems, r)
-	return err
+	return support.InsertResult(qt.Elems, sqlStr), nil
 }
 
 // FindAll 查询多条记录
@@ -331,40 +278,112 @@ func (qt *PgSQL) Create() error {
 //   - []any: 查询结果对象列表
 //   - error: 执行错误
 func (qt *PgSQL) FindAll(queryParts ...any) ([]any, error) {
-	query, args := qt.buildQuery(queryParts...)
+	return qt.FindAllContext(context.Background(), queryParts...)
+}
 
-	// 执行查询
-	rows, err := qt.conn.Query(qt.getSelectSQL(query), args...)
-	elemsLen := len(qt.Elems)
+// FindAllContext 与 FindAll 相同,使用 ctx 控制超时与取消
+func (qt *PgSQL) FindAllContext(ctx context.Context, queryParts ...any) ([]any, error) {
+	ctx, cancel := qt.config.Context(ctx)
+	defer cancel()
+
+	if qt.err != nil {
+		return nil, qt.err
+	}
+
+	q, err := qt.buildQuery(queryParts...)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, nil
-		}
 		return nil, err
 	}
+	return qt.findAll(ctx, &q)
+}
+
+// findAll 查询匹配 q 的所有记录
+func (qt *PgSQL) findAll(ctx context.Context, q *support.Query) ([]any, error) {
+	// 执行查询
+	rows, err := qt.conn.QueryContext(ctx, qt.getSelectSQL(q), q.Args()...)
+	if err != nil {
+		return nil, support.TranslateError(err)
+	}
 	defer func(rows *sql.Rows) {
 		_ = rows.Close()
 	}(rows)
 
 	// 遍历查询结果
-	var objs []any
-	for rows.Next() {
-		obj := reflect.New(qt.objType).Elem()
-		Scans := make([]any, elemsLen)
+	return qt.scanAll(rows)
+}
+
+// Reuse 返回复用行对象的 ObjectORM 副本
+// Each 遍历时所有行都扫描到加载的对象中,而不是为每行分配新对象
+func (qt *PgSQL) Reuse() support.ObjectORM {
+	cp := *qt
+	cp.reuse = true
+	return &cp
+}
+
+// Each 逐行遍历查询结果
+// 每扫描一行调用一次 fn,参数为指向行对象的指针;fn 返回错误时停止遍历并返回该错误
+// 结果集不会整体载入内存,遍历结束或中止时关闭 rows
+// SetTimeout 设置的默认超时只限制查询执行到返回结果集,不限制之后的遍历;需要限制整个遍历时使用 EachContext 传入带截止时间的 ctx
+// 参数:
+//   - fn: 行回调
+//   - queryParts: 查询条件部分,可以是条件字符串和参数
+//
+// 返回:
+//   - error: 执行错误或 fn 返回的错误
+func (qt *PgSQL) Each(fn func(obj any) error, queryParts ...any) error {
+	return qt.EachContext(context.Background(), fn, queryParts...)
+}
+
+// EachContext 与 Each 相同,使用 ctx 控制超时与取消
+func (qt *PgSQL) EachContext(ctx context.Context, fn func(obj any) error, queryParts ...any) error {
+	ctx, started, cancel := qt.config.StreamContext(ctx)
+	defer cancel()
+
+	if qt.err != nil {
+		return qt.err
+	}
+
+	elems := qt.columns()
+	q, err := qt.buildQuery(queryParts...)
+	if err != nil {
+		return err
+	}
+
+	// 执行查询
+	rows, err := qt.conn.QueryContext(ctx, qt.getSelectSQL(&q), q.Args()...)
+	if err != nil {
+		return support.TranslateError(err)
+	}
+	started()
+	defer func(rows *sql.Rows) {
+		_ = rows.Close()
+	}(rows)
+
+	// 复用模式下扫描目标固定为加载对象的字段
+	elemsLen := len(elems)
+	Scans := make([]any, elemsLen)
+	if qt.reuse {
 		for i := 0; i < elemsLen; i++ {
-			// 使用反射获取字段的地址用于扫描
-			Scans[i] = reflect.NewAt(qt.Elems[i].Type, obj.Field(qt.Elems[i].Index).Addr().UnsafePointer()).Interface()
+			Scans[i] = elems[i].GetInterface()
 		}
-		err := rows.Scan(Scans...)
-		if err != nil {
-			if err == sql.ErrNoRows {
-				return nil, nil
-			}
-			return nil, err
+	}
+
+	// 遍历查询结果
+	for rows.Next() {
+		obj := qt.obj
+		if !qt.reuse {
+			objPtr := reflect.New(qt.objType)
+			support.BindScans(Scans, elems, objPtr.UnsafePointer())
+			obj = objPtr.Interface()
+		}
+		if err := rows.Scan(Scans...); err != nil {
+			return support.TranslateError(err)
+		}
+		if err := fn(obj); err != nil {
+			return err
 		}
-		objs = append(objs, obj.Interface())
 	}
-	return objs, nil
+	return support.TranslateError(rows.Err())
 }
 
 // Find 查询单条记录
@@ -374,31 +393,97 @@ func (qt *PgSQL) FindAll(queryParts ...any) ([]any, error) {
 //
 // 返回:
 //   - any: 查询结果对象
-//   - error: 执行错误
+//   - error: 执行错误,没有匹配的记录时为 support.ErrRecordNotFound
 func (qt *PgSQL) Find(queryParts ...any) (any, error) {
-	query, args := qt.buildQuery(queryParts...)
+	return qt.FindContext(context.Background(), queryParts...)
+}
+
+// FindContext 与 Find 相同,使用 ctx 控制超时与取消
+func (qt *PgSQL) FindContext(ctx context.Context, queryParts ...any) (any, error) {
+	ctx, cancel := qt.config.Context(ctx)
+	defer cancel()
+
+	if qt.err != nil {
+		return nil, qt.err
+	}
+
+	elems := qt.columns()
+	q, err := qt.buildQuery(queryParts...)
+	if err != nil {
+		return nil, err
+	}
 
 	// 执行查询
-	row := qt.conn.QueryRow(qt.getSelectSQL(query), args...)
+	row := qt.conn.QueryRowContext(ctx, qt.getSelectSQL(&q), q.Args()...)
 
-	elemsLen := len(qt.Elems)
+	elemsLen := len(elems)
 	Scans := make([]any, elemsLen)
 	for i := 0; i < elemsLen; i++ {
-		Scans[i] = qt.Elems[i].GetInterface()
+		Scans[i] = elems[i].GetInterface()
 	}
 
 	// 扫描结果
-	err := row.Scan(Scans...)
+	err = row.Scan(Scans...)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, nil
+			return nil, support.ErrRecordNotFound
 		}
-		return nil, err
+		return nil, support.TranslateError(err)
 	}
 
 	return qt.obj, nil
 }
 
+// FindByPK 按主键查询单条记录
+// ids 依次对应主键字段,复合主键需按声明顺序传入所有字段的值
+// 参数:
+//   - ids: 主键值
+//
+// 返回:
+//   - any: 查询结果对象
+//   - error: 执行错误
+func (qt *PgSQL) FindByPK(ids ...any) (any, error) {
+	return qt.FindByPKContext(context.Background(), ids...)
+}
+
+// FindByPKContext 与 FindByPK 相同,使用 ctx 控制超时与取消
+func (qt *PgSQL) FindByPKContext(ctx context.Context, ids ...any) (any, error) {
+	if len(ids) == 0 {
+		return nil, errors.New("primary key values required")
+	}
+	query, args, err := support.PrimaryKeyQuery(qt.Elems, '"', ids)
+	if err != nil {
+		return nil, err
+	}
+	if len(args) != len(support.PrimaryKeys(qt.Elems)) {
+		return nil, errors.New("FindByPK accepts exactly one primary key value per primary key column")
+	}
+	return qt.FindContext(ctx, append([]any{query}, args...)...)
+}
+
+// DeleteByPK 按主键删除记录
+// 单列主键可传入多个值批量删除;复合主键按声明顺序传入字段值,可传入字段数整数倍的值删除多条记录
+// 参数:
+//   - ids: 主键值
+//
+// 返回:
+//   - error: 执行错误
+func (qt *PgSQL) DeleteByPK(ids ...any) error {
+	return qt.DeleteByPKContext(context.Background(), ids...)
+}
+
+// DeleteByPKContext 与 DeleteByPK 相同,使用 ctx 控制超时与取消
+func (qt *PgSQL) DeleteByPKContext(ctx context.Context, ids ...any) error {
+	if len(ids) == 0 {
+		return errors.New("primary key values required")
+	}
+	query, args, err := support.PrimaryKeyQuery(qt.Elems, '"', ids)
+	if err != nil {
+		return err
+	}
+	return qt.DeleteContext(ctx, append([]any{query}, args...)...)
+}
+
 // Count 统计记录数量
 // 根据提供的查询条件统计匹配的记录数
 // 参数:
@@ -408,42 +493,61 @@ func (qt *PgSQL) Find(queryParts ...any) (any, error) {
 //   - int: 记录数量
 //   - error: 执行错误
 func (qt *PgSQL) Count(queryParts ...any) (int, error) {
-	query, args := qt.buildQuery(queryParts...)
+	return qt.CountContext(context.Background(), queryParts...)
+}
 
-	// 计算 SQL 语句所需的缓冲区大小
-	tabNameLen := len(qt.Table)
-	queryStringLen := len(query)
-	var buf utils.Buffer
-	if query == "" {
-		buf = utils.NewBuffer(23 + tabNameLen) // SELECT COUNT(*) FROM "table"
+// CountContext 与 Count 相同,使用 ctx 控制超时与取消
+func (qt *PgSQL) CountContext(ctx context.Context, queryParts ...any) (int, error) {
+	ctx, cancel := qt.config.Context(ctx)
+	defer cancel()
+
+	if qt.err != nil {
+		return 0, qt.err
+	}
+	q, err := qt.buildQuery(queryParts...)
+	if err != nil {
+		return 0, err
+	}
+	return qt.count(ctx, q)
+}
+
+// count 统计 q 匹配的记录数量,分组、限制行数或去重时统计子查询的行数
+func (qt *PgSQL) count(ctx context.Context, q support.Query) (int, error) {
+	q.OrderBy = "" // 排序不影响计数
+
+	// DISTINCT 或集合运算时统计结果的行数
+	if qt.distinct || len(q.Sets) > 0 {
+		cp := *qt
+		cp.lock = support.Lock{} // 行锁只作用于返回行的查询
+		sqlStr := "SELECT COUNT(*) FROM (" + cp.getSelectSQL(&q) + ") AS \"t\""
+		var counter int
+		err := qt.conn.QueryRowContext(ctx, sqlStr, q.Args()...).Scan(&counter)
+		return counter, support.TranslateError(err)
+	}
+
+	// 创建缓冲区并构建 COUNT 语句
+	buf := utils.NewBuffer(38 + len(qt.Table) + q.Len()) // SELECT COUNT(*) FROM (SELECT 1 FROM "table" ...) AS "t"
+	n := support.WriteRebind(&buf, q.With, 0)
+	wrap := q.HasClauses()
+	if wrap {
+		buf.WriteString("SELECT COUNT(*) FROM (SELECT 1")
 	} else {
-		buf = utils.NewBuffer(30 + tabNameLen + queryStringLen) // SELECT COUNT(*) FROM "table" WHERE
+		buf.WriteString("SELECT COUNT(*)")
 	}
-	buf.WriteString("SELECT COUNT(*) FROM \"")
-	buf.WriteString(qt.Table)
-	buf.WriteString("\"")
+	support.WriteFrom(&buf, '"', qt.tables())
 
-	// 构建 WHERE 子句
-	if query != "" {
-		buf.WriteString(" WHERE ")
-		// 替换问号为 PostgreSQL 占位符格式($n)
-		whereCounter := 1
-		var processedQuery strings.Builder
-		for _, c := range query {
-			if c == '?' {
-				processedQuery.WriteString(fmt.Sprintf("$%d", whereCounter))
-				whereCounter++
-			} else {
-				processedQuery.WriteRune(c)
-			}
-		}
-		buf.WriteString(processedQuery.String())
+	// 构建 WHERE 及其后的子句
+	tail := utils.NewBuffer(q.Len())
+	q.WriteTo(&tail)
+	support.WriteRebind(&buf, tail.String(), n)
+	if wrap {
+		buf.WriteString(") AS \"t\"")
 	}
 
 	// 执行 COUNT 查询
 	var counter int
-	err := qt.conn.QueryRow(buf.String(), args).Scan(&counter)
-	return counter, err
+	err := qt.conn.QueryRowContext(ctx, buf.String(), q.Args()...).Scan(&counter)
+	return counter, support.TranslateError(err)
 }
 
 // autotType 根据目标类型和源值执行类型转换
@@ -493,60 +597,316 @@ func IsEmpty(err error) bool {
 	return false
 }
 
-// getSelectSQL 生成 SELECT 查询语句
-// 参数:
-//   - queryString: WHERE 子句的条件字符串
-//
-// 返回:
-//   - string: 完整的 SELECT SQL 语句
-func (qt *PgSQL) getSelectSQL(queryString string) string {
-	elemsLeng := len(qt.Elems)
-	tabNameLen := len(qt.Table)
-	queryStringLen := len(queryString)
+// primaryKeyQuery 生成按当前对象主键匹配的条件,未定义主键或主键为零值时返回空条件
+func (qt *PgSQL) primaryKeyQuery() (string, []any) {
+	query, args, err := support.PrimaryKeyQuery(qt.Elems, '"', nil)
+	if err != nil {
+		return "", nil
+	}
+	return query, args
+}
+
+// writeQuery 解析 Update/Save/Delete 的条件
+// 未指定条件时按主键匹配当前对象,主键为零值时视为没有条件;仍没有条件且未允许全表写入时返回 support.ErrMissingWhere
+func (qt *PgSQL) writeQuery(queryParts ...any) (string, []any, error) {
+	if qt.err != nil {
+		return "", nil, qt.err
+	}
+
+	if len(qt.joins) > 0 {
+		return "", nil, errors.New("JOIN is not supported in write operations")
+	}
+	if qt.from != "" {
+		return "", nil, errors.New("From is not supported in write operations")
+	}
+	if len(qt.sets) > 0 {
+		return "", nil, errors.New("set operations are not supported in write operations")
+	}
+	q, err := qt.buildQuery(queryParts...)
+	if err != nil {
+		return "", nil, err
+	}
+	if q.HasClauses() {
+		return "", nil, support.ErrWriteClause
+	}
+	query, args := q.Where, q.WhereArgs
+	// 未指定条件时按主键匹配当前对象
+	if query == "" {
+		query, args = qt.primaryKeyQuery()
+	}
+	if query == "" && !qt.global && !qt.config.AllowGlobal {
+		return "", nil, support.ErrMissingWhere
+	}
+	return query, args, nil
+}
+
+// buildUpdate 生成 UPDATE 语句
+// sets 不为空时只更新 Set 赋值的字段;否则 all 为 false 时仅更新非零值字段(Update),为 true 时更新所有非自增字段(Save)
+// 没有需要更新的字段时返回空字符串
+func (qt *PgSQL) buildUpdate(query string, args []any, sets []support.Assignment, all bool) (string, []any, error) {
+	with, withArgs := support.WithClause('"', qt.ctes)
+	if len(sets) > 0 {
+		return qt.buildAssign(with, withArgs, query, args, sets)
+	}
+	elems := qt.columns()
+	all = all || qt.selected // Select 显式指定的字段写入零值
 
 	// 计算 SQL 语句所需的缓冲区大小
+	elemsLeng := len(elems)
 	elemsNameLength := 0
 	for i := 0; i < elemsLeng; i++ {
-		// 字段名(2个引号) + 逗号(1)
-		elemsNameLength += len(qt.Elems[i].Tag) + 2 + 1
+		if !all && elems[i].Zero() {
+			continue
+		}
+		if elems[i].Option["autoIncrement"] == "-" {
+			continue
+		}
+		// 字段名(2个引号) + "=$n"(4) + 逗号(1)
+		elemsNameLength += len(elems[i].Tag) + 6 + 1
+	}
+	if elemsNameLength == 0 {
+		return "", nil, nil
 	}
 
-	// 创建缓冲区并构建 SELECT 语句
+	// 创建缓冲区并构建 UPDATE 语句
+	buf := utils.NewBuffer(21 + len(qt.Table) + len(query) + elemsNameLength) // UPDATE "table" SET WHERE
+	support.WriteRebind(&buf, with, 0)
+	buf.WriteString("UPDATE \"")
+	buf.WriteString(qt.Table)
+	buf.WriteString("\" SET ")
+
+	// 收集需要更新的字段值并构建 SET 子句,使用 PostgreSQL 的 $n 占位符
+	values := make([]any, 0, len(withArgs)+elemsLeng+len(args))
+	values = append(values, withArgs...)
+	for i := 0; i < elemsLeng; i++ {
+		if !all && elems[i].Zero() {
+			continue
+		}
+		if elems[i].Option["autoIncrement"] == "-" {
+			continue
+		}
+		values = append(values, elems[i].Get())
+		buf.WriteByte('"')
+		buf.WriteString(elems[i].Tag)
+		buf.WriteString("\"=$")
+		buf.WriteString(strconv.Itoa(len(values)))
+		buf.WriteByte(',')
+	}
+	buf.TruncateLast(1) // 移除末尾的逗号
+
+	// 构建 WHERE 子句,占位符编号接在 SET 子句之后
+	if query != "" {
+		buf.WriteString(" WHERE ")
+		support.WriteRebind(&buf, query, len(values))
+		values = append(values, args...)
+	}
+	return buf.String(), values, nil
+}
+
+// buildAssign 生成只更新 Set 赋值字段的 UPDATE 语句,字段值可以是 Expr 表达式
+func (qt *PgSQL) buildAssign(with string, withArgs []any, query string, args []any, sets []support.Assignment) (string, []any, error) {
+	set := utils.NewBuffer(len(sets) * 16)
+	setArgs, err := support.WriteAssignments(&set, support.DialectPostgres, qt.Elems, sets)
+	if err != nil {
+		return "", nil, err
+	}
+
+	// 创建缓冲区并构建 UPDATE 语句
+	buf := utils.NewBuffer(21 + len(with) + len(qt.Table) + len(set) + len(query)) // UPDATE "table" SET WHERE
+	support.WriteRebind(&buf, with, 0)
+	buf.WriteString("UPDATE \"")
+	buf.WriteString(qt.Table)
+	buf.WriteString("\" SET ")
+	support.WriteRebind(&buf, set.String(), len(withArgs))
+	values := make([]any, 0, len(withArgs)+len(setArgs)+len(args))
+	values = append(values, withArgs...)
+	values = append(values, setArgs...)
+
+	// 构建 WHERE 子句,占位符编号接在 SET 子句之后
+	if query != "" {
+		buf.WriteString(" WHERE ")
+		support.WriteRebind(&buf, query, len(values))
+		values = append(values, args...)
+	}
+	return buf.String(), values, nil
+}
+
+// buildDelete 生成 DELETE 语句,返回的参数包含 WITH 子句的参数
+func (qt *PgSQL) buildDelete(query string, args []any) (string, []any) {
+	with, withArgs := support.WithClause('"', qt.ctes)
+
+	// 计算 SQL 语句所需的缓冲区大小
+	tabNameLen := len(qt.Table)
+	queryStringLen := len(query)
 	var buf utils.Buffer
-	if queryString == "" {
-		buf = utils.NewBuffer(15 + tabNameLen + elemsNameLength) // SELECT ... FROM "table"
+	if query == "" {
+		buf = utils.NewBuffer(14 + tabNameLen) // DELETE FROM "table"
 	} else {
-		buf = utils.NewBuffer(22 + tabNameLen + queryStringLen + elemsNameLength) // SELECT ... FROM "table" WHERE
+		buf = utils.NewBuffer(20 + tabNameLen + queryStringLen) // DELETE FROM "table" WHERE
+	}
+	n := support.WriteRebind(&buf, with, 0)
+	buf.WriteString("DELETE FROM \"")
+	buf.WriteString(qt.Table)
+	buf.WriteByte('"')
+
+	// 构建 WHERE 子句,替换问号为 PostgreSQL 占位符格式($n)
+	if query != "" {
+		buf.WriteString(" WHERE ")
+		support.WriteRebind(&buf, query, n)
+	}
+	if len(withArgs) > 0 {
+		args = append(withArgs, args...)
+	}
+	return buf.String(), args
+}
+
+// buildInsert 生成插入 inserts 字段的 INSERT 语句
+func (qt *PgSQL) buildInsert(inserts []support.Elem) (string, []any) {
+	// 计算 SQL 语句所需的缓冲区大小
+	elemsLeng := len(inserts)
+	elemsNameLength := 0
+	for i := 0; i < elemsLeng; i++ {
+		// 字段名(2个引号) + 逗号(1) + 占位符与逗号(4)
+		elemsNameLength += len(inserts[i].Tag) + 3 + 4
+	}
+
+	// 创建缓冲区并构建 INSERT 语句
+	buf := utils.NewBuffer(29 + len(qt.Table) + elemsNameLength) // INSERT INTO "table" (...) VALUES (...)
+	buf.WriteString("INSERT INTO \"")
+	buf.WriteString(qt.Table)
+	buf.WriteByte('"')
+
+	// 所有字段均由数据库生成
+	if elemsLeng == 0 {
+		buf.WriteString(" DEFAULT VALUES")
+		return buf.String(), nil
 	}
-	buf.WriteString("SELECT ")
 
 	// 构建字段列表
+	buf.WriteString(" (")
+	values := make([]any, 0, elemsLeng)
 	for i := 0; i < elemsLeng; i++ {
 		buf.WriteByte('"')
-		buf.WriteString(qt.Elems[i].Tag)
+		buf.WriteString(inserts[i].Tag)
 		buf.WriteByte('"')
+		values = append(values, inserts[i].Get())
 		buf.WriteByte(',')
 	}
 	buf.TruncateLast(1) // 移除末尾的逗号
+	buf.WriteString(") VALUES (")
 
-	// 构建 FROM 和 WHERE 子句
-	buf.WriteString(" FROM \"")
-	buf.WriteString(qt.Table)
-	buf.WriteByte('"')
-	if queryString != "" {
-		buf.WriteString(" WHERE ")
-		// 替换问号为 PostgreSQL 占位符格式($n)
-		whereCounter := 1
-		var processedQuery strings.Builder
-		for _, c := range queryString {
-			if c == '?' {
-				processedQuery.WriteString(fmt.Sprintf("$%d", whereCounter))
-				whereCounter++
-			} else {
-				processedQuery.WriteRune(c)
-			}
+	// 构建 VALUES 子句,使用 PostgreSQL 的 $n 占位符
+	for i := 0; i < elemsLeng; i++ {
+		buf.WriteByte('$')
+		buf.WriteString(strconv.Itoa(i + 1))
+		buf.WriteByte(',')
+	}
+	buf.TruncateLast(1) // 移除末尾的逗号
+	buf.WriteByte(')')
+	return buf.String(), values
+}
+
+// scanAll 将 rows 中的所有行扫描为新的对象
+func (qt *PgSQL) scanAll(rows *sql.Rows) ([]any, error) {
+	elems := qt.columns()
+	elemsLen := len(elems)
+	var objs []any
+	for rows.Next() {
+		obj := reflect.New(qt.objType).Elem()
+		Scans := make([]any, elemsLen)
+		for i := 0; i < elemsLen; i++ {
+			// 使用反射获取字段的地址用于扫描
+			Scans[i] = reflect.NewAt(elems[i].Type, obj.Field(elems[i].Index).Addr().UnsafePointer()).Interface()
+		}
+		if err := rows.Scan(Scans...); err != nil {
+			return nil, support.TranslateError(err)
+		}
+		objs = append(objs, obj.Interface())
+	}
+	return objs, support.TranslateError(rows.Err())
+}
+
+// writeReturning 写入 RETURNING 子句并返回 elems 字段的扫描目标
+func writeReturning(buf *utils.Buffer, elems []support.Elem) []any {
+	Scans := make([]any, len(elems))
+	buf.WriteString(" RETURNING ")
+	for i := 0; i < len(elems); i++ {
+		buf.WriteByte('"')
+		buf.WriteString(elems[i].Tag)
+		buf.WriteString("\",")
+		Scans[i] = elems[i].GetInterface()
+	}
+	buf.TruncateLast(1) // 移除末尾的逗号
+	return Scans
+}
+
+// getSelectSQL 生成 SELECT 查询语句,同时将字段列表中表达式的参数写入 q.SelectArgs
+// 参数:
+//   - q: 查询条件,渲染为 WHERE 及其后的子句
+//
+// 返回:
+//   - string: 完整的 SELECT SQL 语句
+func (qt *PgSQL) getSelectSQL(q *support.Query) string {
+	elems := qt.columns()
+	elemsLeng := len(elems)
+	tabNameLen := len(qt.Table)
+
+	// 计算 SQL 语句所需的缓冲区大小
+	elemsNameLength := 0
+	for i := 0; i < elemsLeng; i++ {
+		// 字段名(2个引号) + 逗号(1)
+		elemsNameLength += len(elems[i].Tag) + 2 + 1
+	}
+
+	// 创建缓冲区并构建 SELECT 语句
+	buf := utils.NewBuffer(24 + tabNameLen + elemsNameLength + q.Len()) // SELECT ... FROM "table" WHERE ...
+	// WITH 子句中的占位符先编号,其余部分从其后继续
+	n := support.WriteRebind(&buf, q.With, 0)
+	head := utils.NewBuffer(16 + tabNameLen + elemsNameLength)
+	if qt.distinct {
+		head.WriteString("SELECT DISTINCT ")
+	} else {
+		head.WriteString("SELECT ")
+	}
+
+	// 构建字段列表,连接其他表时以表名限定字段名,SelectExpr 指定的字段以表达式代替
+	q.SelectArgs = nil
+	for i := 0; i < elemsLeng; i++ {
+		if j := support.IndexExpr(qt.exprs, elems[i].Tag); j != -1 {
+			head.WriteByte('(')
+			head.WriteString(qt.exprs[j].SQL)
+			head.WriteString(") AS \"")
+			head.WriteString(elems[i].Tag)
+			head.WriteString("\",")
+			q.SelectArgs = append(q.SelectArgs, qt.exprs[j].Args...)
+			continue
+		}
+		if len(qt.joins) > 0 {
+			head.WriteByte('"')
+			head.WriteString(qt.source())
+			head.WriteString("\".")
 		}
-		buf.WriteString(processedQuery.String())
+		head.WriteByte('"')
+		head.WriteString(elems[i].Tag)
+		head.WriteByte('"')
+		head.WriteByte(',')
+	}
+	head.TruncateLast(1) // 移除末尾的逗号
+
+	// 构建 FROM 及 JOIN 子句,字段列表中表达式的占位符接在 WITH 子句之后编号
+	support.WriteFrom(&head, '"', qt.tables())
+	n = support.WriteRebind(&buf, head.String(), n)
+
+	// 构建 WHERE、集合运算及其后的子句,集合运算的各个 SELECT 与主查询共用字段列表与 FROM 子句
+	// 替换问号为 PostgreSQL 占位符格式($n)
+	tail := utils.NewBuffer(q.Len())
+	q.WriteCompound(&tail, head.String())
+	support.WriteRebind(&buf, tail.String(), n)
+
+	// 构建行锁子句
+	if lock := qt.lock.String(); lock != "" {
+		buf.WriteByte(' ')
+		buf.WriteString(lock)
 	}
 	return buf.String()
 }
@@ -0,0 +1,899 @@
+// Copyright 2024 OblivionOcean
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pg_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/base64"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/OblivionOcean/opao"
+	"github.com/OblivionOcean/opao/internal/fakedb"
+	"github.com/OblivionOcean/opao/support"
+	"github.com/OblivionOcean/opao/support/pg"
+)
+
+type User struct {
+	Id   int64  `db:"id" option:"primaryKey;autoIncrement"`
+	Name string `db:"name"`
+	Age  int    `db:"age"`
+}
+
+type Order struct {
+	Id     int64 `db:"id" option:"primaryKey;autoIncrement"`
+	UserId int64 `db:"user_id" option:"references=user.id"`
+	Amount int64 `db:"amount"`
+}
+
+// newDB 创建使用 fakedb 的 Database,注册 User 与 Order
+func newDB(t *testing.T) (*opao.Database, *fakedb.Driver) {
+	t.Helper()
+	d := &fakedb.Driver{Query: func(query string, args []any) (fakedb.Rows, error) {
+		if strings.Contains(query, "COUNT(*)") {
+			return fakedb.Value(int64(0)), nil
+		}
+		return fakedb.Rows{}, nil
+	}}
+	db := &opao.Database{Conn: fakedb.Open(d)}
+	db.ORM.Init(db.Conn, pg.NewPg)
+	db.Config().Dialect = support.DialectPostgres
+	if err := db.Register("user", &User{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Register("order", &Order{}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	return db, d
+}
+
+// sqlCase 执行 run 后检查最后一条 SQL 与参数
+type sqlCase struct {
+	name string
+	run  func(db *opao.Database) error
+	sql  string
+	args []any
+}
+
+func runSQLCases(t *testing.T, cases []sqlCase) {
+	t.Helper()
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			db, d := newDB(t)
+			if err := c.run(db); err != nil {
+				t.Fatal(err)
+			}
+			checkCall(t, d.Last(), c.sql, c.args)
+		})
+	}
+}
+
+func checkCall(t *testing.T, call fakedb.Call, sql string, args []any) {
+	t.Helper()
+	if call.SQL != sql {
+		t.Errorf("sql:\n got %s\nwant %s", call.SQL, sql)
+	}
+	if len(call.Args) != 0 || len(args) != 0 {
+		if !reflect.DeepEqual(call.Args, args) {
+			t.Errorf("args: got %#v, want %#v", call.Args, args)
+		}
+	}
+}
+
+func TestCount(t *testing.T) {
+	count := func(parts ...any) func(db *opao.Database) error {
+		return func(db *opao.Database) error {
+			_, err := db.Load(&User{}).Count(parts...)
+			return err
+		}
+	}
+	runSQLCases(t, []sqlCase{
+		{"all", count(), "SELECT COUNT(*) FROM \"user\"", nil},
+		{"string", count("age > ?", 18), "SELECT COUNT(*) FROM \"user\" WHERE age > $1", []any{18}},
+		{"conditions", count(opao.Eq("name", "a"), opao.Gt("age", 1)), "SELECT COUNT(*) FROM \"user\" WHERE name = $1 AND age > $2", []any{"a", 1}},
+		{"order ignored", count(opao.Gt("age", 1), opao.Desc("age")), "SELECT COUNT(*) FROM \"user\" WHERE age > $1", []any{1}},
+		{"group", count(opao.GroupBy("age")), "SELECT COUNT(*) FROM (SELECT 1 FROM \"user\" GROUP BY \"age\") AS \"t\"", nil},
+		{"distinct", func(db *opao.Database) error {
+			_, err := db.Load(&User{}).Distinct("name").Count()
+			return err
+		}, "SELECT COUNT(*) FROM (SELECT DISTINCT \"name\" FROM \"user\") AS \"t\"", nil},
+	})
+}
+
+func TestCountNotRegistered(t *testing.T) {
+	db, d := newDB(t)
+	type Unknown struct {
+		Id int64 `db:"id"`
+	}
+	if _, err := db.Load(&Unknown{}).Count(); err == nil || !strings.Contains(err.Error(), "not registered") {
+		t.Fatalf("got %v, want not registered error", err)
+	}
+	if len(d.Calls()) != 0 {
+		t.Fatalf("unexpected SQL: %v", d.Calls())
+	}
+}
+
+func TestCreateBatch(t *testing.T) {
+	db, d := newDB(t)
+	next := int64(10)
+	d.Query = func(query string, args []any) (fakedb.Rows, error) {
+		if !strings.HasPrefix(query, "INSERT") {
+			return fakedb.Rows{}, nil
+		}
+		// RETURNING 按插入顺序返回自增值
+		r := fakedb.Rows{Columns: []string{"id"}}
+		for i := 0; i < len(args)/2; i++ {
+			r.Values = append(r.Values, []any{next})
+			next++
+		}
+		return r, nil
+	}
+	users := []User{{Name: "a", Age: 1}, {Name: "b", Age: 2}, {Name: "c", Age: 3}}
+	if err := db.Load(&User{}).CreateBatch(users, 2); err != nil {
+		t.Fatal(err)
+	}
+
+	var inserts []fakedb.Call
+	for _, call := range d.Calls() {
+		if strings.HasPrefix(call.SQL, "INSERT") {
+			inserts = append(inserts, call)
+		}
+	}
+	if len(inserts) != 2 {
+		t.Fatalf("got %d INSERT statements, want 2", len(inserts))
+	}
+	checkCall(t, inserts[0], "INSERT INTO \"user\" (\"name\",\"age\") VALUES ($1,$2),($3,$4) RETURNING \"id\"", []any{"a", 1, "b", 2})
+	checkCall(t, inserts[1], "INSERT INTO \"user\" (\"name\",\"age\") VALUES ($1,$2) RETURNING \"id\"", []any{"c", 3})
+	if ids := []int64{users[0].Id, users[1].Id, users[2].Id}; !reflect.DeepEqual(ids, []int64{10, 11, 12}) {
+		t.Errorf("ids: got %v, want [10 11 12]", ids)
+	}
+}
+
+func TestCreateBatchReturningErrors(t *testing.T) {
+	cases := []struct {
+		name string
+		rows fakedb.Rows
+	}{
+		{"short", fakedb.Rows{Columns: []string{"id"}, Values: [][]any{{int64(10)}}}},
+		{"scan", fakedb.Rows{Columns: []string{"id"}, Values: [][]any{{"x"}, {"y"}}}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			db, d := newDB(t)
+			d.Query = func(string, []any) (fakedb.Rows, error) { return c.rows, nil }
+			users := []User{{Name: "a", Age: 1}, {Name: "b", Age: 2}}
+			if err := db.Load(&User{}).CreateBatch(users, 0); err == nil {
+				t.Fatal("want error")
+			}
+		})
+	}
+}
+
+func TestCreateBatchNotRegistered(t *testing.T) {
+	db, d := newDB(t)
+	type Unknown struct {
+		Id int64 `db:"id"`
+	}
+	if err := db.Load(&Unknown{}).CreateBatch([]Unknown{{Id: 1}}, 0); err == nil {
+		t.Fatal("want error for unregistered model")
+	}
+	if len(d.Calls()) != 0 {
+		t.Fatalf("unexpected SQL: %v", d.Calls())
+	}
+}
+
+func TestUpsert(t *testing.T) {
+	upsert := func(u User, conflictColumns ...string) func(db *opao.Database) error {
+		return func(db *opao.Database) error {
+			return db.Load(&u).Upsert(conflictColumns...)
+		}
+	}
+	runSQLCases(t, []sqlCase{
+		{"primary key", upsert(User{Id: 5, Name: "a", Age: 1}), "INSERT INTO \"user\" (\"id\",\"name\",\"age\") VALUES ($1,$2,$3) ON CONFLICT (\"id\") DO UPDATE SET \"name\"=EXCLUDED.\"name\",\"age\"=EXCLUDED.\"age\" RETURNING \"id\"", []any{int64(5), "a", 1}},
+		{"zero primary key", upsert(User{Name: "a", Age: 1}), "INSERT INTO \"user\" (\"name\",\"age\") VALUES ($1,$2) ON CONFLICT (\"id\") DO UPDATE SET \"name\"=EXCLUDED.\"name\",\"age\"=EXCLUDED.\"age\" RETURNING \"id\"", []any{"a", 1}},
+		{"conflict on primary key", upsert(User{Name: "a", Age: 1}, "id"), "INSERT INTO \"user\" (\"id\",\"name\",\"age\") VALUES ($1,$2,$3) ON CONFLICT (\"id\") DO UPDATE SET \"name\"=EXCLUDED.\"name\",\"age\"=EXCLUDED.\"age\" RETURNING \"id\"", []any{int64(0), "a", 1}},
+		{"conflict on column", upsert(User{Name: "a", Age: 1}, "name"), "INSERT INTO \"user\" (\"name\",\"age\") VALUES ($1,$2) ON CONFLICT (\"name\") DO UPDATE SET \"age\"=EXCLUDED.\"age\" RETURNING \"id\"", []any{"a", 1}},
+	})
+}
+
+func TestUpsertNotRegistered(t *testing.T) {
+	db, d := newDB(t)
+	type Unknown struct {
+		Id int64 `db:"id"`
+	}
+	if err := db.Load(&Unknown{Id: 1}).Upsert(); err == nil {
+		t.Fatal("want error for unregistered model")
+	}
+	if len(d.Calls()) != 0 {
+		t.Fatalf("unexpected SQL: %v", d.Calls())
+	}
+}
+
+func TestFind(t *testing.T) {
+	find := func(u User, parts ...any) func(db *opao.Database) error {
+		return func(db *opao.Database) error {
+			_, err := db.Load(&u).Find(parts...)
+			if errors.Is(err, opao.ErrRecordNotFound) {
+				return nil
+			}
+			return err
+		}
+	}
+	runSQLCases(t, []sqlCase{
+		{"no condition ignores primary key", find(User{Id: 5}), "SELECT \"id\",\"name\",\"age\" FROM \"user\"", nil},
+		{"string", find(User{}, "name = ?", "a"), "SELECT \"id\",\"name\",\"age\" FROM \"user\" WHERE name = $1", []any{"a"}},
+		{"conditions", find(User{}, opao.Eq("name", "a"), opao.Desc("age")), "SELECT \"id\",\"name\",\"age\" FROM \"user\" WHERE name = $1 ORDER BY \"age\" DESC", []any{"a"}},
+		{"by primary key", func(db *opao.Database) error {
+			_, err := db.Load(&User{}).FindByPK(5)
+			if errors.Is(err, opao.ErrRecordNotFound) {
+				return nil
+			}
+			return err
+		}, "SELECT \"id\",\"name\",\"age\" FROM \"user\" WHERE \"id\" = $1", []any{5}},
+	})
+}
+
+func TestFindNotFound(t *testing.T) {
+	db, _ := newDB(t)
+	if _, err := db.Load(&User{}).Find("id = ?", 1); !errors.Is(err, opao.ErrRecordNotFound) {
+		t.Fatalf("got %v, want ErrRecordNotFound", err)
+	}
+}
+
+func TestWritePrimaryKey(t *testing.T) {
+	runSQLCases(t, []sqlCase{
+		{"update", func(db *opao.Database) error {
+			return db.Load(&User{Id: 5, Name: "a"}).Update()
+		}, "UPDATE \"user\" SET \"name\"=$1 WHERE \"id\" = $2", []any{"a", int64(5)}},
+		{"delete", func(db *opao.Database) error {
+			return db.Load(&User{Id: 5}).Delete()
+		}, "DELETE FROM \"user\" WHERE \"id\" = $1", []any{int64(5)}},
+	})
+	db, d := newDB(t)
+	if err := db.Load(&User{Name: "a"}).Update(); !errors.Is(err, opao.ErrMissingWhere) {
+		t.Fatalf("update: got %v, want ErrMissingWhere", err)
+	}
+	if err := db.Load(&User{}).Delete(); !errors.Is(err, opao.ErrMissingWhere) {
+		t.Fatalf("delete: got %v, want ErrMissingWhere", err)
+	}
+	if len(d.Calls()) != 0 {
+		t.Fatalf("unexpected SQL: %v", d.Calls())
+	}
+}
+
+func TestLock(t *testing.T) {
+	db, d := newDB(t)
+	if _, err := db.Load(&User{}).ForUpdate().FindAll(); !errors.Is(err, opao.ErrLockOutsideTx) {
+		t.Fatalf("got %v, want ErrLockOutsideTx", err)
+	}
+	if len(d.Calls()) != 0 {
+		t.Fatalf("unexpected SQL: %v", d.Calls())
+	}
+	err := db.Transaction(context.Background(), func(tx *opao.Tx) error {
+		_, err := tx.Load(&User{}).ForUpdate().SkipLocked().FindAll(opao.Gt("age", 1))
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	calls := d.Calls()
+	if len(calls) != 3 {
+		t.Fatalf("got %d calls, want BEGIN, SELECT and COMMIT: %v", len(calls), calls)
+	}
+	checkCall(t, calls[1], "SELECT \"id\",\"name\",\"age\" FROM \"user\" WHERE age > $1 FOR UPDATE SKIP LOCKED", []any{1})
+}
+
+type UserOrder struct {
+	User
+	Order *Order
+}
+
+func TestJoin(t *testing.T) {
+	scan := func(join func(db *opao.Database) support.ObjectORM, parts ...any) func(db *opao.Database) error {
+		return func(db *opao.Database) error {
+			var rows []UserOrder
+			return join(db).Scan(&rows, parts...)
+		}
+	}
+	runSQLCases(t, []sqlCase{
+		{"references", scan(func(db *opao.Database) support.ObjectORM {
+			return db.Load(&User{}).LeftJoin(&Order{})
+		}, opao.Gte("user.age", 18), opao.Desc("order.id")), "SELECT \"user\".\"id\" AS \"user.id\",\"user\".\"name\" AS \"user.name\",\"user\".\"age\" AS \"user.age\",\"order\".\"id\" AS \"order.id\",\"order\".\"user_id\" AS \"order.user_id\",\"order\".\"amount\" AS \"order.amount\" FROM \"user\" LEFT JOIN \"order\" ON \"order\".\"user_id\" = \"user\".\"id\" WHERE user.age >= $1 ORDER BY \"order\".\"id\" DESC", []any{18}},
+		{"raw on", scan(func(db *opao.Database) support.ObjectORM {
+			return db.Load(&User{}).Join(&Order{}, "order.user_id = user.id")
+		}), "SELECT \"user\".\"id\" AS \"user.id\",\"user\".\"name\" AS \"user.name\",\"user\".\"age\" AS \"user.age\",\"order\".\"id\" AS \"order.id\",\"order\".\"user_id\" AS \"order.user_id\",\"order\".\"amount\" AS \"order.amount\" FROM \"user\" JOIN \"order\" ON order.user_id = user.id", nil},
+		{"column pair", scan(func(db *opao.Database) support.ObjectORM {
+			return db.Load(&User{}).InnerJoin(&Order{}, "id", "user_id")
+		}), "SELECT \"user\".\"id\" AS \"user.id\",\"user\".\"name\" AS \"user.name\",\"user\".\"age\" AS \"user.age\",\"order\".\"id\" AS \"order.id\",\"order\".\"user_id\" AS \"order.user_id\",\"order\".\"amount\" AS \"order.amount\" FROM \"user\" INNER JOIN \"order\" ON \"user\".\"id\" = \"order\".\"user_id\"", nil},
+		{"qualified column pair", scan(func(db *opao.Database) support.ObjectORM {
+			return db.Load(&User{}).Join(&Order{}, "user.id", "order.user_id")
+		}), "SELECT \"user\".\"id\" AS \"user.id\",\"user\".\"name\" AS \"user.name\",\"user\".\"age\" AS \"user.age\",\"order\".\"id\" AS \"order.id\",\"order\".\"user_id\" AS \"order.user_id\",\"order\".\"amount\" AS \"order.amount\" FROM \"user\" JOIN \"order\" ON \"user\".\"id\" = \"order\".\"user_id\"", nil},
+	})
+}
+
+func TestJoinOnErrors(t *testing.T) {
+	db, d := newDB(t)
+	var rows []UserOrder
+	for _, on := range [][]string{{"id", "user_id", "amount"}, {"missing", "user_id"}, {"id", "missing"}, {"other.id", "user_id"}} {
+		if err := db.Load(&User{}).Join(&Order{}, on...).Scan(&rows); err == nil {
+			t.Errorf("%v: want error", on)
+		}
+	}
+	if len(d.Calls()) != 0 {
+		t.Fatalf("unexpected SQL: %v", d.Calls())
+	}
+}
+
+func TestJoinScanByName(t *testing.T) {
+	db, d := newDB(t)
+	// 列的顺序与查询的字段不同,按名称写入
+	d.Query = func(query string, args []any) (fakedb.Rows, error) {
+		return fakedb.Rows{
+			Columns: []string{"order.amount", "user.name", "order.id", "user.id", "order.user_id", "user.age"},
+			Values: [][]any{
+				{int64(100), "a", int64(7), int64(1), int64(1), int64(20)},
+				{nil, "b", nil, int64(2), nil, int64(30)},
+			},
+		}, nil
+	}
+	var rows []UserOrder
+	if err := db.Load(&User{}).LeftJoin(&Order{}).Scan(&rows); err != nil {
+		t.Fatal(err)
+	}
+	want := []UserOrder{
+		{User{Id: 1, Name: "a", Age: 20}, &Order{Id: 7, UserId: 1, Amount: 100}},
+		{User{Id: 2, Name: "b", Age: 30}, nil},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("got %+v, want %+v", rows, want)
+	}
+
+	d.Query = func(query string, args []any) (fakedb.Rows, error) {
+		return fakedb.Rows{Columns: []string{"user.id", "other"}, Values: [][]any{{int64(1), int64(2)}}}, nil
+	}
+	if err := db.Load(&User{}).LeftJoin(&Order{}).Scan(&rows); err == nil || !strings.Contains(err.Error(), "unknown column: other") {
+		t.Errorf("got %v, want unknown column error", err)
+	}
+}
+
+func TestSetOperation(t *testing.T) {
+	runSQLCases(t, []sqlCase{
+		{"union", func(db *opao.Database) error {
+			_, err := db.Load(&User{}).Union(opao.Gt("age", 60)).FindAll(opao.Lt("age", 18), opao.Asc("id"))
+			return err
+		}, "SELECT \"id\",\"name\",\"age\" FROM \"user\" WHERE age < $1 UNION SELECT \"id\",\"name\",\"age\" FROM \"user\" WHERE age > $2 ORDER BY \"id\" ASC", []any{18, 60}},
+		{"intersect", func(db *opao.Database) error {
+			_, err := db.Load(&User{}).Intersect(opao.Gt("age", 60)).FindAll(opao.Lt("age", 18))
+			return err
+		}, "SELECT \"id\",\"name\",\"age\" FROM \"user\" WHERE age < $1 INTERSECT SELECT \"id\",\"name\",\"age\" FROM \"user\" WHERE age > $2", []any{18, 60}},
+	})
+}
+
+func TestRaw(t *testing.T) {
+	scan := func(query string, args ...any) func(db *opao.Database) error {
+		return func(db *opao.Database) error {
+			var users []User
+			return db.Raw(query, args...).Scan(&users)
+		}
+	}
+	named := func(query string, arg any) func(db *opao.Database) error {
+		return func(db *opao.Database) error {
+			var users []User
+			return db.RawNamed(query, arg).Scan(&users)
+		}
+	}
+	runSQLCases(t, []sqlCase{
+		{"placeholders", scan("SELECT * FROM users WHERE age > ? AND name = '?'", 18), "SELECT * FROM users WHERE age > $1 AND name = '?'", []any{18}},
+		{"named", named("SELECT * FROM users WHERE age > :min AND name <> ':name'", map[string]any{"min": 18}), "SELECT * FROM users WHERE age > $1 AND name <> ':name'", []any{18}},
+	})
+}
+
+func TestRawScan(t *testing.T) {
+	db, d := newDB(t)
+	d.Query = func(query string, args []any) (fakedb.Rows, error) {
+		return fakedb.Rows{
+			Columns: []string{"age", "extra", "id", "name"},
+			Values:  [][]any{{int64(20), "x", int64(1), "a"}, {nil, "y", int64(2), "b"}},
+		}, nil
+	}
+	var users []*User
+	if err := db.Raw("SELECT * FROM users").Scan(&users); err != nil {
+		t.Fatal(err)
+	}
+	want := []*User{{Id: 1, Name: "a", Age: 20}, {Id: 2, Name: "b"}}
+	if !reflect.DeepEqual(users, want) {
+		t.Errorf("got %+v, want %+v", users, want)
+	}
+	if err := db.Raw("SELECT * FROM users").Strict().Scan(&users); err == nil || !strings.Contains(err.Error(), "unknown column: extra") {
+		t.Errorf("got %v, want unknown column error", err)
+	}
+
+	d.Query = nil
+	var user User
+	if err := db.Raw("SELECT * FROM users").Scan(&user); !errors.Is(err, opao.ErrRecordNotFound) {
+		t.Errorf("got %v, want ErrRecordNotFound", err)
+	}
+}
+
+func TestNamedCondition(t *testing.T) {
+	find := func(parts ...any) func(db *opao.Database) error {
+		return func(db *opao.Database) error {
+			_, err := db.Load(&User{}).FindAll(parts...)
+			return err
+		}
+	}
+	runSQLCases(t, []sqlCase{
+		{"numbering", find(opao.Eq("id", 1), opao.Named("age > :min AND name <> ':name?'", map[string]any{"min": 18}), opao.Eq("name", "a")), "SELECT \"id\",\"name\",\"age\" FROM \"user\" WHERE id = $1 AND age > $2 AND name <> ':name?' AND name = $3", []any{1, 18, "a"}},
+		{"struct", find(opao.Named("name = :name", &User{Name: "a"})), "SELECT \"id\",\"name\",\"age\" FROM \"user\" WHERE name = $1", []any{"a"}},
+		{"quoted placeholder", find("name = '?' AND age > ?", 1), "SELECT \"id\",\"name\",\"age\" FROM \"user\" WHERE name = '?' AND age > $1", []any{1}},
+	})
+}
+
+func TestTranslateError(t *testing.T) {
+	db, d := newDB(t)
+	driverErr := errors.New("pq: duplicate key value violates unique constraint \"user_name_key\"")
+	d.Exec = func(query string, args []any) (driver.Result, error) { return nil, driverErr }
+	d.Query = func(query string, args []any) (fakedb.Rows, error) { return fakedb.Rows{}, driverErr }
+	if err := db.Load(&User{Name: "a"}).Create(); !errors.Is(err, opao.ErrDuplicateKey) || !errors.Is(err, driverErr) {
+		t.Errorf("create: got %v, want ErrDuplicateKey wrapping the driver error", err)
+	}
+	if err := db.Load(&User{Id: 1, Name: "a"}).Update(); !errors.Is(err, opao.ErrDuplicateKey) {
+		t.Errorf("update: got %v, want ErrDuplicateKey", err)
+	}
+}
+
+func TestTransaction(t *testing.T) {
+	const update = "UPDATE \"user\" SET \"name\"=$1 WHERE \"id\" = $2"
+	errFail := errors.New("fail")
+	update1 := func(tx *opao.Tx) error { return tx.Load(&User{Id: 1, Name: "a"}).Update() }
+	cases := []struct {
+		name  string
+		fn    func(tx *opao.Tx) error
+		err   error
+		panic bool
+		calls []string
+	}{
+		{"commit", update1, nil, false, []string{"BEGIN", update, "COMMIT"}},
+		{"rollback on error", func(tx *opao.Tx) error {
+			if err := update1(tx); err != nil {
+				return err
+			}
+			return errFail
+		}, errFail, false, []string{"BEGIN", update, "ROLLBACK"}},
+		{"rollback on panic", func(tx *opao.Tx) error {
+			_ = update1(tx)
+			panic("boom")
+		}, nil, true, []string{"BEGIN", update, "ROLLBACK"}},
+		{"nested release", func(tx *opao.Tx) error {
+			return tx.Transaction(context.Background(), update1)
+		}, nil, false, []string{"BEGIN", "SAVEPOINT opao_sp_1", update, "RELEASE SAVEPOINT opao_sp_1", "COMMIT"}},
+		{"nested rollback", func(tx *opao.Tx) error {
+			err := tx.Transaction(context.Background(), func(tx *opao.Tx) error {
+				return tx.Transaction(context.Background(), func(tx *opao.Tx) error {
+					_ = update1(tx)
+					return errFail
+				})
+			})
+			if !errors.Is(err, errFail) {
+				t.Errorf("nested: got %v, want %v", err, errFail)
+			}
+			return nil
+		}, nil, false, []string{"BEGIN", "SAVEPOINT opao_sp_1", "SAVEPOINT opao_sp_2", update, "ROLLBACK TO SAVEPOINT opao_sp_2", "ROLLBACK TO SAVEPOINT opao_sp_1", "COMMIT"}},
+		{"nested panic", func(tx *opao.Tx) error {
+			return tx.Transaction(context.Background(), func(tx *opao.Tx) error {
+				panic("boom")
+			})
+		}, nil, true, []string{"BEGIN", "SAVEPOINT opao_sp_1", "ROLLBACK TO SAVEPOINT opao_sp_1", "ROLLBACK"}},
+		{"nested options", func(tx *opao.Tx) error {
+			for _, opt := range []*sql.TxOptions{{Isolation: sql.LevelSerializable}, {ReadOnly: true}} {
+				if err := tx.Transaction(context.Background(), update1, opt); err == nil {
+					t.Errorf("%+v: want error", opt)
+				}
+			}
+			return tx.Transaction(context.Background(), update1, &sql.TxOptions{})
+		}, nil, false, []string{"BEGIN", "SAVEPOINT opao_sp_1", update, "RELEASE SAVEPOINT opao_sp_1", "COMMIT"}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			db, d := newDB(t)
+			var err error
+			func() {
+				defer func() {
+					if p := recover(); (p != nil) != c.panic {
+						t.Errorf("panic: got %v, want %v", p, c.panic)
+					}
+				}()
+				err = db.Transaction(context.Background(), c.fn)
+			}()
+			if !c.panic && !errors.Is(err, c.err) {
+				t.Errorf("got %v, want %v", err, c.err)
+			}
+			var calls []string
+			for _, call := range d.Calls() {
+				calls = append(calls, call.SQL)
+			}
+			if !reflect.DeepEqual(calls, c.calls) {
+				t.Errorf("calls:\n got %q\nwant %q", calls, c.calls)
+			}
+		})
+	}
+}
+
+func TestTransactionContext(t *testing.T) {
+	db, d := newDB(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	called := false
+	err := db.Transaction(ctx, func(tx *opao.Tx) error {
+		called = true
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) || called {
+		t.Fatalf("got %v (fn called: %v), want context.Canceled", err, called)
+	}
+	if len(d.Calls()) != 0 {
+		t.Fatalf("unexpected SQL: %v", d.Calls())
+	}
+}
+
+// userRows 返回 n 行 User 结果
+func userRows(n int) fakedb.Rows {
+	rows := fakedb.Rows{Columns: []string{"id", "name", "age"}}
+	for i := 1; i <= n; i++ {
+		rows.Values = append(rows.Values, []any{int64(i), "u", int64(i)})
+	}
+	return rows
+}
+
+func TestEach(t *testing.T) {
+	for _, reuse := range []bool{false, true} {
+		db, d := newDB(t)
+		d.Query = func(string, []any) (fakedb.Rows, error) { return userRows(3), nil }
+		orm := db.Load(&User{})
+		if reuse {
+			orm = orm.Reuse()
+		}
+		var ptrs []*User
+		var ids []int64
+		err := orm.Each(func(obj any) error {
+			u := obj.(*User)
+			ptrs = append(ptrs, u)
+			ids = append(ids, u.Id)
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(ids, []int64{1, 2, 3}) {
+			t.Errorf("reuse %v: ids got %v", reuse, ids)
+		}
+		if same := ptrs[0] == ptrs[1] && ptrs[1] == ptrs[2]; same != reuse {
+			t.Errorf("reuse %v: same object for every row: %v", reuse, same)
+		}
+	}
+}
+
+func TestEachStop(t *testing.T) {
+	db, d := newDB(t)
+	d.Query = func(string, []any) (fakedb.Rows, error) { return userRows(3), nil }
+	errStop := errors.New("stop")
+	n := 0
+	err := db.Load(&User{}).Each(func(obj any) error {
+		n++
+		return errStop
+	})
+	if !errors.Is(err, errStop) || n != 1 {
+		t.Fatalf("got %v after %d rows, want errStop after 1 row", err, n)
+	}
+}
+
+func TestEachTimeout(t *testing.T) {
+	db, d := newDB(t)
+	d.Query = func(string, []any) (fakedb.Rows, error) { return userRows(3), nil }
+	db.SetTimeout(20 * time.Millisecond)
+	n := 0
+	err := db.Load(&User{}).Each(func(obj any) error {
+		n++
+		time.Sleep(15 * time.Millisecond)
+		return nil
+	})
+	if err != nil || n != 3 {
+		t.Fatalf("got %v after %d rows, want all 3 rows", err, n)
+	}
+}
+
+func TestMissingWhere(t *testing.T) {
+	cases := []struct {
+		name string
+		run  func(orm support.ObjectORM) error
+	}{
+		{"update", func(orm support.ObjectORM) error { return orm.Update() }},
+		{"save", func(orm support.ObjectORM) error { return orm.Save() }},
+		{"delete", func(orm support.ObjectORM) error { return orm.Delete() }},
+		{"update columns", func(orm support.ObjectORM) error {
+			return orm.UpdateColumns(map[string]any{"age": 1})
+		}},
+		{"delete returning", func(orm support.ObjectORM) error {
+			_, err := orm.DeleteReturning()
+			return err
+		}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			db, d := newDB(t)
+			if err := c.run(db.Load(&User{Name: "a"})); !errors.Is(err, opao.ErrMissingWhere) {
+				t.Fatalf("got %v, want ErrMissingWhere", err)
+			}
+			if len(d.Calls()) != 0 {
+				t.Fatalf("unexpected SQL: %v", d.Calls())
+			}
+		})
+	}
+}
+
+func TestAllowGlobal(t *testing.T) {
+	runSQLCases(t, []sqlCase{
+		{"allow global", func(db *opao.Database) error {
+			return db.Load(&User{Name: "a"}).AllowGlobal().Update()
+		}, "UPDATE \"user\" SET \"name\"=$1", []any{"a"}},
+		{"set allow global", func(db *opao.Database) error {
+			db.SetAllowGlobal(true)
+			return db.Load(&User{}).Delete()
+		}, "DELETE FROM \"user\"", nil},
+	})
+}
+
+func TestCreateReturning(t *testing.T) {
+	db, d := newDB(t)
+	d.Query = func(string, []any) (fakedb.Rows, error) { return fakedb.Value(int64(42)), nil }
+	u := &User{Name: "a", Age: 1}
+	res, err := db.Load(u).CreateWithResult()
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkCall(t, d.Last(), "INSERT INTO \"user\" (\"name\",\"age\") VALUES ($1,$2) RETURNING \"id\"", []any{"a", 1})
+	if u.Id != 42 || res.LastInsertId != 42 || res.RowsAffected != 1 {
+		t.Errorf("got id %d, result %+v, want 42", u.Id, res)
+	}
+}
+
+func TestUpdateReturning(t *testing.T) {
+	db, d := newDB(t)
+	d.Query = func(string, []any) (fakedb.Rows, error) { return userRows(2), nil }
+	objs, err := db.Load(&User{}).UpdateReturning(opao.Set("age", 3), "name = ?", "u")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkCall(t, d.Last(), "UPDATE \"user\" SET \"age\"=$1 WHERE name = $2 RETURNING \"id\",\"name\",\"age\"", []any{3, "u"})
+	if len(d.Calls()) != 1 || len(objs) != 2 || objs[1].(User).Id != 2 {
+		t.Errorf("got %d calls, objs %v", len(d.Calls()), objs)
+	}
+}
+
+func TestDeleteReturning(t *testing.T) {
+	db, d := newDB(t)
+	d.Query = func(string, []any) (fakedb.Rows, error) { return userRows(2), nil }
+	objs, err := db.Load(&User{}).DeleteReturning("name = ?", "u")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkCall(t, d.Last(), "DELETE FROM \"user\" WHERE name = $1 RETURNING \"id\",\"name\",\"age\"", []any{"u"})
+	if len(d.Calls()) != 1 || len(objs) != 2 || objs[1].(User).Id != 2 {
+		t.Errorf("got %d calls, objs %v", len(d.Calls()), objs)
+	}
+}
+
+func TestPaginate(t *testing.T) {
+	db, d := newDB(t)
+	d.Query = func(query string, args []any) (fakedb.Rows, error) {
+		if strings.Contains(query, "COUNT(*)") {
+			return fakedb.Value(int64(5)), nil
+		}
+		return userRows(2), nil
+	}
+	page, err := db.Load(&User{}).Paginate(2, 2, "age > ?", 1, opao.Asc("id"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkCall(t, d.Last(), "SELECT \"id\",\"name\",\"age\" FROM \"user\" WHERE age > $1 ORDER BY \"id\" ASC LIMIT $2 OFFSET $3", []any{1, 2, 2})
+	if page.Total != 5 || page.Pages != 3 || len(page.Items) != 2 {
+		t.Errorf("got %+v", page)
+	}
+}
+
+func TestCursorPaginate(t *testing.T) {
+	db, d := newDB(t)
+	d.Query = func(string, []any) (fakedb.Rows, error) { return userRows(3), nil }
+	parts := []any{"name = ?", "u", opao.Desc("age"), opao.Asc("id")}
+	first, err := db.Load(&User{}).CursorPaginate("", 2, parts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkCall(t, d.Last(), "SELECT \"id\",\"name\",\"age\" FROM \"user\" WHERE name = $1 ORDER BY \"age\" DESC,\"id\" ASC LIMIT $2", []any{"u", 3})
+	if !first.HasNext || len(first.Items) != 2 || first.NextCursor == "" {
+		t.Fatalf("got %+v", first)
+	}
+
+	// 游标条件与原 WHERE 组合,降序字段取小于游标值的行
+	if _, err = db.Load(&User{}).CursorPaginate(first.NextCursor, 2, parts...); err != nil {
+		t.Fatal(err)
+	}
+	checkCall(t, d.Last(), "SELECT \"id\",\"name\",\"age\" FROM \"user\" WHERE (name = $1) AND ((\"age\" < $2) OR (\"age\" = $3 AND \"id\" > $4)) ORDER BY \"age\" DESC,\"id\" ASC LIMIT $5", []any{"u", 2, 2, int64(2), 3})
+}
+
+func TestCursorErrors(t *testing.T) {
+	db, d := newDB(t)
+	d.Query = func(string, []any) (fakedb.Rows, error) { return userRows(2), nil }
+	first, err := db.Load(&User{}).CursorPaginate("", 1, opao.Asc("id"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		name   string
+		cursor string
+		order  []any
+	}{
+		{"tampered encoding", first.NextCursor + "!", []any{opao.Asc("id")}},
+		{"tampered value", base64.RawURLEncoding.EncodeToString([]byte(`{"c":["id"],"v":["x"]}`)), []any{opao.Asc("id")}},
+		{"other column", first.NextCursor, []any{opao.Asc("age")}},
+		{"extra column", first.NextCursor, []any{opao.Desc("age"), opao.Asc("id")}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			d.Reset()
+			if _, err := db.Load(&User{}).CursorPaginate(c.cursor, 1, c.order...); err == nil {
+				t.Fatal("want error")
+			}
+			if len(d.Calls()) != 0 {
+				t.Fatalf("unexpected SQL: %v", d.Calls())
+			}
+		})
+	}
+}
+
+func TestSubqueryNumbering(t *testing.T) {
+	// 子查询的参数按出现位置与外层参数统一编号
+	find := func(parts ...any) func(db *opao.Database) error {
+		return func(db *opao.Database) error {
+			_, err := db.Load(&User{}).FindAll(parts...)
+			return err
+		}
+	}
+	runSQLCases(t, []sqlCase{
+		{"in subquery", find(
+			opao.Eq("name", "a"),
+			opao.InSubquery("id", opao.Subquery(&Order{}, "user_id", "amount > ?", 100)),
+			opao.Gt("age", 18),
+		), "SELECT \"id\",\"name\",\"age\" FROM \"user\" WHERE name = $1 AND id IN (SELECT \"user_id\" FROM \"order\" WHERE amount > $2) AND age > $3", []any{"a", 100, 18}},
+		{"exists", find(
+			opao.Eq("name", "a"),
+			opao.Exists(opao.Subquery(&Order{}, "", `"order"."user_id" = "user"."id" AND amount > ?`, 100)),
+			opao.Gt("age", 18),
+		), "SELECT \"id\",\"name\",\"age\" FROM \"user\" WHERE name = $1 AND EXISTS (SELECT 1 FROM \"order\" WHERE \"order\".\"user_id\" = \"user\".\"id\" AND amount > $2) AND age > $3", []any{"a", 100, 18}},
+		{"custom", find(
+			opao.Eq("name", "a"),
+			opao.Exists(opao.Custom("SELECT 1 FROM \"order\" WHERE amount > ?", 100)),
+			opao.Gt("age", 18),
+		), "SELECT \"id\",\"name\",\"age\" FROM \"user\" WHERE name = $1 AND EXISTS (SELECT 1 FROM \"order\" WHERE amount > $2) AND age > $3", []any{"a", 100, 18}},
+	})
+}
+
+func TestCTE(t *testing.T) {
+	adults := opao.Subquery(&User{}, "id", "age > ?", 18)
+	findAll := func(orm func(db *opao.Database) support.ObjectORM, parts ...any) func(db *opao.Database) error {
+		return func(db *opao.Database) error {
+			_, err := orm(db).FindAll(parts...)
+			return err
+		}
+	}
+	// 公用表表达式的参数排在语句其余参数之前
+	runSQLCases(t, []sqlCase{
+		{"find all", findAll(func(db *opao.Database) support.ObjectORM {
+			return db.Load(&User{}).With("adults", adults)
+		}, "id IN (SELECT id FROM adults) AND name = ?", "a"), "WITH \"adults\" AS (SELECT \"id\" FROM \"user\" WHERE age > $1) SELECT \"id\",\"name\",\"age\" FROM \"user\" WHERE id IN (SELECT id FROM adults) AND name = $2", []any{18, "a"}},
+		{"update", func(db *opao.Database) error {
+			return db.Load(&User{Name: "b"}).With("adults", adults).Update("id IN (SELECT id FROM adults) AND name = ?", "a")
+		}, "WITH \"adults\" AS (SELECT \"id\" FROM \"user\" WHERE age > $1) UPDATE \"user\" SET \"name\"=$2 WHERE id IN (SELECT id FROM adults) AND name = $3", []any{18, "b", "a"}},
+		{"delete", func(db *opao.Database) error {
+			return db.Load(&User{}).With("adults", adults).Delete("id IN (SELECT id FROM adults) AND name = ?", "a")
+		}, "WITH \"adults\" AS (SELECT \"id\" FROM \"user\" WHERE age > $1) DELETE FROM \"user\" WHERE id IN (SELECT id FROM adults) AND name = $2", []any{18, "a"}},
+		{"recursive from", findAll(func(db *opao.Database) support.ObjectORM {
+			return db.Load(&User{}).WithRecursive("tree(id,name,age)", opao.Custom(
+				"SELECT id,name,age FROM \"user\" WHERE id = ? UNION ALL SELECT u.id,u.name,u.age FROM \"user\" u JOIN tree t ON u.age = t.id", 1,
+			)).From("tree")
+		}, "age > ?", 2), "WITH RECURSIVE \"tree\"(id,name,age) AS (SELECT id,name,age FROM \"user\" WHERE id = $1 UNION ALL SELECT u.id,u.name,u.age FROM \"user\" u JOIN tree t ON u.age = t.id) SELECT \"id\",\"name\",\"age\" FROM \"tree\" WHERE age > $2", []any{1, 2}},
+		{"from", findAll(func(db *opao.Database) support.ObjectORM {
+			return db.Load(&User{}).From("active_user")
+		}, "age > ?", 2), "SELECT \"id\",\"name\",\"age\" FROM \"active_user\" WHERE age > $1", []any{2}},
+	})
+}
+
+func TestFromWrite(t *testing.T) {
+	db, d := newDB(t)
+	if err := db.Load(&User{Id: 1, Name: "a"}).From("active_user").Update(); err == nil {
+		t.Fatal("update: want error for From")
+	}
+	if err := db.Load(&User{Id: 1}).From("active_user").Delete(); err == nil {
+		t.Fatal("delete: want error for From")
+	}
+	if len(d.Calls()) != 0 {
+		t.Fatalf("unexpected S\"L: %v", d.Calls())
+	}
+}
+
+func TestExpr(t *testing.T) {
+	findAll := func(exprs ...support.Expr) func(db *opao.Database) error {
+		return func(db *opao.Database) error {
+			_, err := db.Load(&User{}).SelectExpr(exprs...).FindAll("age > ?", 2)
+			return err
+		}
+	}
+	runSQLCases(t, []sqlCase{
+		{"set expr", func(db *opao.Database) error {
+			return db.Load(&User{}).Update(opao.Set("age", opao.Expr("age + ?", 1)), "name = ?", "a")
+		}, "UPDATE \"user\" SET \"age\"=age + $1 WHERE name = $2", []any{1, "a"}},
+		{"set coalesce", func(db *opao.Database) error {
+			return db.Load(&User{}).Update(opao.Set("name", opao.Coalesce("name", "anon")), opao.Set("age", 3), "id = ?", 1)
+		}, "UPDATE \"user\" SET \"name\"=COALESCE(\"name\",$1),\"age\"=$2 WHERE id = $3", []any{"anon", 3, 1}},
+		{"select coalesce", findAll(opao.Coalesce("name", opao.Expr("?", "anon")).As("name")), "SELECT \"id\",(COALESCE(\"name\",$1)) AS \"name\",\"age\" FROM \"user\" WHERE age > $2", []any{"anon", 2}},
+		{"select now", findAll(opao.Now().As("name")), "SELECT \"id\",(NOW()) AS \"name\",\"age\" FROM \"user\" WHERE age > $1", []any{2}},
+		{"select date trunc", findAll(opao.DateTrunc("month", opao.Now()).As("name"), opao.Expr("age * ?", 2).As("age")), "SELECT \"id\",(DATE_TRUNC('month',NOW())) AS \"name\",(age * $1) AS \"age\" FROM \"user\" WHERE age > $2", []any{2, 2}},
+	})
+}
+
+func TestUpdateColumns(t *testing.T) {
+	// 零值同样写入
+	runSQLCases(t, []sqlCase{
+		{"zero values", func(db *opao.Database) error {
+			return db.Load(&User{Id: 5}).UpdateColumns(map[string]any{"age": 0, "name": ""})
+		}, "UPDATE \"user\" SET \"name\"=$1,\"age\"=$2 WHERE \"id\" = $3", []any{"", 0, int64(5)}},
+		{"with condition", func(db *opao.Database) error {
+			return db.Load(&User{}).UpdateColumns(map[string]any{"age": opao.Expr("age + ?", 1)}, "name = ?", "a")
+		}, "UPDATE \"user\" SET \"age\"=age + $1 WHERE name = $2", []any{1, "a"}},
+		{"fields", func(db *opao.Database) error {
+			return db.Load(&User{Id: 5, Name: "a"}).UpdateFields("age")
+		}, "UPDATE \"user\" SET \"age\"=$1 WHERE \"id\" = $2", []any{0, int64(5)}},
+	})
+}
+
+func TestUpdateColumnsErrors(t *testing.T) {
+	cases := []struct {
+		name string
+		run  func(orm support.ObjectORM) error
+	}{
+		{"unknown column", func(orm support.ObjectORM) error {
+			return orm.UpdateColumns(map[string]any{"age": 1, "nickname": "a"})
+		}},
+		{"unknown field", func(orm support.ObjectORM) error { return orm.UpdateFields("nickname") }},
+		{"no fields", func(orm support.ObjectORM) error { return orm.UpdateFields() }},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			db, d := newDB(t)
+			if err := c.run(db.Load(&User{Id: 5})); err == nil {
+				t.Fatal("want error")
+			}
+			if len(d.Calls()) != 0 {
+				t.Fatalf("unexpected SQL: %v", d.Calls())
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+// Copyright 2024 OblivionOcean
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pg
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/OblivionOcean/opao/support"
+	"github.com/OblivionOcean/opao/utils"
+)
+
+// UpdateReturning 更新记录并通过 RETURNING 返回更新后的记录
+// 参数:
+//   - queryParts: 查询条件部分,可以是条件字符串和参数
+//
+// 返回:
+//   - []any: 更新后的记录
+//   - error: 执行错误
+func (qt *PgSQL) UpdateReturning(queryParts ...any) ([]any, error) {
+	return qt.UpdateReturningContext(context.Background(), queryParts...)
+}
+
+// UpdateReturningContext 与 UpdateReturning 相同,使用 ctx 控制超时与取消
+func (qt *PgSQL) UpdateReturningContext(ctx context.Context, queryParts ...any) ([]any, error) {
+	ctx, cancel := qt.config.Context(ctx)
+	defer cancel()
+
+	queryParts, sets := support.SplitAssignments(queryParts)
+	query, args, err := qt.writeQuery(queryParts...)
+	if err != nil {
+		return nil, err
+	}
+	sqlStr, values, err := qt.buildUpdate(query, args, sets, false)
+	if err != nil {
+		return nil, err
+	}
+	if sqlStr == "" {
+		return nil, nil
+	}
+	return qt.queryReturning(ctx, sqlStr, values)
+}
+
+// DeleteReturning 删除记录并通过 RETURNING 返回被删除的记录
+// 参数:
+//   - queryParts: 查询条件部分,可以是条件字符串和参数
+//
+// 返回:
+//   - []any: 被删除的记录
+//   - error: 执行错误
+func (qt *PgSQL) DeleteReturning(queryParts ...any) ([]any, error) {
+	return qt.DeleteReturningContext(context.Background(), queryParts...)
+}
+
+// DeleteReturningContext 与 DeleteReturning 相同,使用 ctx 控制超时与取消
+func (qt *PgSQL) DeleteReturningContext(ctx context.Context, queryParts ...any) ([]any, error) {
+	ctx, cancel := qt.config.Context(ctx)
+	defer cancel()
+
+	query, args, err := qt.writeQuery(queryParts...)
+	if err != nil {
+		return nil, err
+	}
+	sqlStr, args := qt.buildDelete(query, args)
+	return qt.queryReturning(ctx, sqlStr, args)
+}
+
+// queryReturning 为 sqlStr 追加返回查询字段的 RETURNING 子句并扫描结果
+func (qt *PgSQL) queryReturning(ctx context.Context, sqlStr string, args []any) ([]any, error) {
+	elems := qt.columns()
+	buf := utils.NewBuffer(len(sqlStr) + 11 + len(elems)*8)
+	buf.WriteString(sqlStr)
+	writeReturning(&buf, elems)
+
+	rows, err := qt.conn.QueryContext(ctx, buf.String(), args...)
+	if err != nil {
+		return nil, support.TranslateError(err)
+	}
+	defer func(rows *sql.Rows) {
+		_ = rows.Close()
+	}(rows)
+	return qt.scanAll(rows)
+}
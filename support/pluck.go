@@ -0,0 +1,54 @@
+// Copyright 2024 OblivionOcean
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package support
+
+import (
+	"database/sql"
+	"errors"
+	"reflect"
+)
+
+// PluckSlice 校验 Pluck 的目标并返回其指向的切片
+// dest 必须是切片指针,元素类型可以是字段类型、可由驱动转换的其他类型或 any
+func PluckSlice(dest any) (reflect.Value, error) {
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Slice {
+		return reflect.Value{}, errors.New("pluck destination must be a pointer to a slice")
+	}
+	return v.Elem(), nil
+}
+
+// ScanPluck 将单列查询结果依次写入 slice,覆盖原有内容
+// 切片元素为 any 时按字段 elem 的类型扫描,否则直接扫描为切片元素类型
+func ScanPluck(rows *sql.Rows, elem Elem, slice reflect.Value) error {
+	itemType := slice.Type().Elem()
+	scanType := itemType
+	if itemType.Kind() == reflect.Interface {
+		scanType = elem.Type
+	}
+	items := slice.Slice(0, 0)
+	for rows.Next() {
+		item := reflect.New(scanType)
+		if err := rows.Scan(item.Interface()); err != nil {
+			return err
+		}
+		items = reflect.Append(items, item.Elem())
+	}
+	if err := rows.Err(); err != nil {
+		return TranslateError(err)
+	}
+	slice.Set(items)
+	return nil
+}
@@ -0,0 +1,115 @@
+// Copyright 2024 OblivionOcean
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package support
+
+import (
+	"errors"
+
+	"github.com/OblivionOcean/opao/utils"
+)
+
+// PrimaryKeys 返回主键字段在 elems 中的下标,按声明顺序排列
+// 通过 option:"primaryKey" 声明,可声明多个字段组成复合主键;
+// 未声明时使用 autoIncrement 字段作为主键
+func PrimaryKeys(elems []Elem) []int {
+	var pks []int
+	for i := 0; i < len(elems); i++ {
+		if elems[i].Option["primaryKey"] == "-" {
+			pks = append(pks, i)
+		}
+	}
+	if len(pks) > 0 {
+		return pks
+	}
+	for i := 0; i < len(elems); i++ {
+		if elems[i].Option["autoIncrement"] == "-" {
+			return []int{i}
+		}
+	}
+	return nil
+}
+
+// PrimaryKeyColumns 返回主键字段名
+func PrimaryKeyColumns(elems []Elem) []string {
+	pks := PrimaryKeys(elems)
+	cols := make([]string, len(pks))
+	for i := 0; i < len(pks); i++ {
+		cols[i] = elems[pks[i]].Tag
+	}
+	return cols
+}
+
+// PrimaryKeyQuery 生成按主键匹配的条件字符串与参数,使用 ? 占位符,quote 为方言的标识符引号
+// ids 为空时使用对象当前的主键字段值,主键字段均为零值时返回空条件;否则 ids 依次对应主键字段,
+// 单列主键可传入多个值生成 IN 条件,复合主键可传入主键字段数整数倍的值匹配多条记录
+func PrimaryKeyQuery(elems []Elem, quote byte, ids []any) (string, []any, error) {
+	pks := PrimaryKeys(elems)
+	if len(pks) == 0 {
+		return "", nil, ErrNoPrimaryKey
+	}
+	if len(ids) == 0 {
+		zero := true
+		ids = make([]any, len(pks))
+		for i := 0; i < len(pks); i++ {
+			ids[i] = elems[pks[i]].Get()
+			zero = zero && elems[pks[i]].Zero()
+		}
+		// 未设置主键的对象不能定位记录
+		if zero {
+			return "", nil, nil
+		}
+	}
+	if len(ids)%len(pks) != 0 {
+		return "", nil, errors.New("number of primary key values does not match primary key columns")
+	}
+
+	buf := utils.NewBuffer(len(ids) * 16)
+	// 单列主键多值使用 IN
+	if len(pks) == 1 && len(ids) > 1 {
+		buf.WriteByte(quote)
+		buf.WriteString(elems[pks[0]].Tag)
+		buf.WriteByte(quote)
+		buf.WriteString(" IN (")
+		for i := 0; i < len(ids); i++ {
+			buf.WriteString("?,")
+		}
+		buf.TruncateLast(1) // 移除末尾的逗号
+		buf.WriteByte(')')
+		return buf.String(), ids, nil
+	}
+
+	rows := len(ids) / len(pks)
+	for r := 0; r < rows; r++ {
+		if r > 0 {
+			buf.WriteString(" OR ")
+		}
+		if rows > 1 {
+			buf.WriteByte('(')
+		}
+		for i := 0; i < len(pks); i++ {
+			if i > 0 {
+				buf.WriteString(" AND ")
+			}
+			buf.WriteByte(quote)
+			buf.WriteString(elems[pks[i]].Tag)
+			buf.WriteByte(quote)
+			buf.WriteString(" = ?")
+		}
+		if rows > 1 {
+			buf.WriteByte(')')
+		}
+	}
+	return buf.String(), ids, nil
+}
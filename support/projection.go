@@ -0,0 +1,91 @@
+// Copyright 2024 OblivionOcean
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package support
+
+import (
+	"errors"
+
+	"github.com/OblivionOcean/opao/utils"
+)
+
+// SelectElems 返回 columns 指定的字段,按注册顺序排列,字段名必须已注册
+func SelectElems(elems []Elem, columns []string) ([]Elem, error) {
+	if len(columns) == 0 {
+		return nil, errors.New("no columns selected")
+	}
+	for i := 0; i < len(columns); i++ {
+		if IndexElem(elems, columns[i]) == -1 {
+			return nil, errors.New("unknown select column: " + columns[i])
+		}
+	}
+	fields := make([]Elem, 0, len(columns))
+	for i := 0; i < len(elems); i++ {
+		if utils.ContainsInSlice(columns, elems[i].Tag) {
+			fields = append(fields, elems[i])
+		}
+	}
+	return fields, nil
+}
+
+// OmitElems 返回 fields 中排除 columns 后的字段,字段名必须已在 elems 中注册
+func OmitElems(elems []Elem, fields []Elem, columns []string) ([]Elem, error) {
+	for i := 0; i < len(columns); i++ {
+		if IndexElem(elems, columns[i]) == -1 {
+			return nil, errors.New("unknown omit column: " + columns[i])
+		}
+	}
+	omitted := make([]Elem, 0, len(fields))
+	for i := 0; i < len(fields); i++ {
+		if !utils.ContainsInSlice(columns, fields[i].Tag) {
+			omitted = append(omitted, fields[i])
+		}
+	}
+	if len(omitted) == 0 {
+		return nil, errors.New("all columns omitted")
+	}
+	return omitted, nil
+}
+
+// SelectExprs 按方言渲染 exprs 并合并到 current,别名相同的表达式替换已有的表达式
+// 表达式的别名必须是 elems 中注册的字段名,查询时该字段以表达式的结果代替
+func SelectExprs(d Dialect, elems []Elem, current, exprs []Expr) ([]Expr, error) {
+	merged := append([]Expr(nil), current...)
+	for i := 0; i < len(exprs); i++ {
+		if IndexElem(elems, exprs[i].Alias) == -1 {
+			return nil, errors.New("select expression alias must be a registered column: " + exprs[i].Alias)
+		}
+		sqlStr, args, err := exprs[i].Render(d)
+		if err != nil {
+			return nil, err
+		}
+		rendered := Expr{SQL: sqlStr, Args: args, Alias: exprs[i].Alias}
+		if j := IndexExpr(merged, rendered.Alias); j != -1 {
+			merged[j] = rendered
+		} else {
+			merged = append(merged, rendered)
+		}
+	}
+	return merged, nil
+}
+
+// IndexExpr 返回别名为 column 的表达式的下标,不存在时返回 -1
+func IndexExpr(exprs []Expr, column string) int {
+	for i := 0; i < len(exprs); i++ {
+		if exprs[i].Alias == column {
+			return i
+		}
+	}
+	return -1
+}
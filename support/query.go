@@ -0,0 +1,275 @@
+// Copyright 2024 OblivionOcean
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package support
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/OblivionOcean/opao/utils"
+)
+
+// Query 解析后的查询条件,各子句按 SQL 顺序渲染在 WHERE 之后,With 渲染在语句开头
+// 所有子句均使用 ? 占位符
+type Query struct {
+	With       string        // WITH 子句,包含关键字与末尾的空格
+	WithArgs   []any         // WITH 子句参数
+	SelectArgs []any         // 字段列表中表达式的参数,由生成 SELECT 语句时写入
+	Where      string        // WHERE 条件,不含 WHERE 关键字
+	WhereArgs  []any         // WHERE 条件参数
+	Sets       []SetQuery    // UNION 等集合运算追加的 SELECT
+	GroupBy    string        // GROUP BY 字段列表,不含关键字
+	Having     string        // HAVING 条件,不含关键字
+	HavingArgs []any         // HAVING 条件参数
+	OrderBy    string        // ORDER BY 字段列表,不含关键字
+	Order      []OrderColumn // ORDER BY 字段,用于游标分页
+	After      string        // 游标分页的游标,由 ApplyCursor 转换为 WHERE 条件
+	Limit      string        // LIMIT/OFFSET 子句,包含关键字
+	LimitArgs  []any         // LIMIT/OFFSET 参数
+}
+
+// OrderColumn ORDER BY 中的一个字段
+type OrderColumn struct {
+	Column string // 字段名
+	Desc   bool   // 是否降序
+}
+
+// HasClauses 判断是否包含 WHERE 之外的子句
+func (q *Query) HasClauses() bool {
+	return q.GroupBy != "" || q.Having != "" || q.OrderBy != "" || q.Limit != ""
+}
+
+// Args 返回按子句顺序排列的所有参数,依次为 WITH 子句、字段列表与 WHERE 条件的参数,
+// 集合运算的每个 SELECT 重复字段列表的参数,之后是 HAVING 与 LIMIT 的参数
+func (q *Query) Args() []any {
+	if len(q.WithArgs) == 0 && len(q.SelectArgs) == 0 && len(q.Sets) == 0 && len(q.HavingArgs) == 0 && len(q.LimitArgs) == 0 {
+		return q.WhereArgs
+	}
+	args := make([]any, 0, len(q.WithArgs)+len(q.SelectArgs)+len(q.WhereArgs)+len(q.HavingArgs)+len(q.LimitArgs))
+	args = append(args, q.WithArgs...)
+	args = append(args, q.SelectArgs...)
+	args = append(args, q.WhereArgs...)
+	for i := 0; i < len(q.Sets); i++ {
+		args = append(args, q.SelectArgs...)
+		args = append(args, q.Sets[i].Args...)
+	}
+	args = append(args, q.HavingArgs...)
+	return append(args, q.LimitArgs...)
+}
+
+// Len 返回渲染后 WITH、WHERE 及其后子句的大致长度,用于预分配缓冲区
+func (q *Query) Len() int {
+	n := len(q.With) + len(q.Where) + len(q.GroupBy) + len(q.Having) + len(q.OrderBy) + len(q.Limit) + 36
+	for i := 0; i < len(q.Sets); i++ {
+		n += len(q.Sets[i].Where) + 128
+	}
+	return n
+}
+
+// WriteTo 将 WHERE、GROUP BY、HAVING、ORDER BY、LIMIT 子句依次写入 buf,子句前带空格
+func (q *Query) WriteTo(buf *utils.Buffer) {
+	if q.Where != "" {
+		buf.WriteString(" WHERE ")
+		buf.WriteString(q.Where)
+	}
+	q.WriteClauses(buf)
+}
+
+// WriteCompound 与 WriteTo 相同,但在 WHERE 条件之后写入集合运算追加的 SELECT
+// head 为各个 SELECT 共用的 SELECT ... FROM ... 部分,其后的子句作用于集合运算的结果
+func (q *Query) WriteCompound(buf *utils.Buffer, head string) {
+	if q.Where != "" {
+		buf.WriteString(" WHERE ")
+		buf.WriteString(q.Where)
+	}
+	for i := 0; i < len(q.Sets); i++ {
+		buf.WriteByte(' ')
+		buf.WriteString(q.Sets[i].Op)
+		buf.WriteByte(' ')
+		buf.WriteString(head)
+		if q.Sets[i].Where != "" {
+			buf.WriteString(" WHERE ")
+			buf.WriteString(q.Sets[i].Where)
+		}
+	}
+	q.WriteClauses(buf)
+}
+
+// WriteClauses 将 WHERE 之后的子句依次写入 buf,子句前带空格
+func (q *Query) WriteClauses(buf *utils.Buffer) {
+	if q.GroupBy != "" {
+		buf.WriteString(" GROUP BY ")
+		buf.WriteString(q.GroupBy)
+	}
+	if q.Having != "" {
+		buf.WriteString(" HAVING ")
+		buf.WriteString(q.Having)
+	}
+	if q.OrderBy != "" {
+		buf.WriteString(" ORDER BY ")
+		buf.WriteString(q.OrderBy)
+	}
+	if q.Limit != "" {
+		buf.WriteByte(' ')
+		buf.WriteString(q.Limit)
+	}
+}
+
+// SplitClauses 从条件字符串的参数中分离出追加在末尾的子句条件
+func SplitClauses(args []any) ([]any, []Condition) {
+	end := len(args)
+	for end > 0 {
+		cond, ok := args[end-1].(Condition)
+		if !ok || !cond.IsClause() {
+			break
+		}
+		end--
+	}
+	if end == len(args) {
+		return args, nil
+	}
+	clauses := make([]Condition, 0, len(args)-end)
+	for i := end; i < len(args); i++ {
+		clauses = append(clauses, args[i].(Condition))
+	}
+	return args[:end], clauses
+}
+
+// AddClause 将 GROUP BY、ORDER BY、LIMIT 或 AFTER 条件写入 q,字段名必须已在 elems 中注册
+// quote 为方言的标识符引号;HAVING 条件需要方言解析,不在此处理
+func AddClause(q *Query, elems []Elem, quote byte, cond Condition) error {
+	switch cond.Type {
+	case GROUP_BY:
+		buf := utils.NewBuffer(len(cond.Args) * 16)
+		for i := 0; i < len(cond.Args); i++ {
+			column, ok := cond.Args[i].(string)
+			if !ok || IndexElem(elems, column) == -1 {
+				return errors.New("unknown group by column: " + toString(cond.Args[i]))
+			}
+			writeColumn(&buf, quote, column)
+			buf.WriteByte(',')
+		}
+		buf.TruncateLast(1) // 移除末尾的逗号
+		q.GroupBy = joinClause(q.GroupBy, buf.String())
+	case ORDER_BY:
+		buf := utils.NewBuffer(len(cond.Args)*16 + 16)
+		if err := writeOrder(&buf, q, elems, quote, cond); err != nil {
+			return err
+		}
+		q.OrderBy = joinClause(q.OrderBy, buf.String())
+	case LIMIT:
+		if q.Limit != "" {
+			return errors.New("multiple LIMIT conditions")
+		}
+		if cond.Left == nil {
+			return errors.New("LIMIT condition requires a limit")
+		}
+		if cond.Right == nil {
+			q.Limit = "LIMIT ?"
+			q.LimitArgs = []any{cond.Left}
+		} else {
+			q.Limit = "LIMIT ? OFFSET ?"
+			q.LimitArgs = []any{cond.Left, cond.Right}
+		}
+	case SET:
+		// Update 在解析条件前分离 SET 赋值,其他操作不支持
+		return errors.New("SET is only supported in Update")
+	case AFTER:
+		cursor, ok := cond.Left.(string)
+		if !ok {
+			return errors.New("AFTER condition requires a cursor string")
+		}
+		if q.After != "" && cursor != "" {
+			return errors.New("multiple AFTER conditions")
+		}
+		if cursor != "" {
+			q.After = cursor
+		}
+	default:
+		return errors.New("not a clause condition")
+	}
+	return nil
+}
+
+// writeOrder 写入 ORDER BY 字段列表
+// Left 为字段名时表示单个字段,Right 为排序方向;否则 Args 中依次为字段名或 Asc/Desc 条件
+func writeOrder(buf *utils.Buffer, q *Query, elems []Elem, quote byte, cond Condition) error {
+	if cond.Left != nil {
+		column, _ := cond.Left.(string)
+		if IndexElem(elems, column) == -1 {
+			return errors.New("unknown order by column: " + toString(cond.Left))
+		}
+		writeColumn(buf, quote, column)
+		direction, _ := cond.Right.(string)
+		if direction != "" {
+			buf.WriteByte(' ')
+			buf.WriteString(direction)
+		}
+		q.Order = append(q.Order, OrderColumn{Column: column, Desc: direction == "DESC"})
+		return nil
+	}
+	if len(cond.Args) == 0 {
+		return errors.New("ORDER BY condition requires at least one column")
+	}
+	for i := 0; i < len(cond.Args); i++ {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		switch arg := cond.Args[i].(type) {
+		case string:
+			if err := writeOrder(buf, q, elems, quote, Condition{Type: ORDER_BY, Left: arg}); err != nil {
+				return err
+			}
+		case Condition:
+			if arg.Type != ORDER_BY {
+				return errors.New("ORDER BY arguments must be column names or Asc/Desc conditions")
+			}
+			if err := writeOrder(buf, q, elems, quote, arg); err != nil {
+				return err
+			}
+		default:
+			return errors.New("unknown order by column: " + toString(arg))
+		}
+	}
+	return nil
+}
+
+// writeColumn 写入带引号的字段名,table.column 形式的限定名分别为表名与字段名加引号
+func writeColumn(buf *utils.Buffer, quote byte, column string) {
+	if i := strings.IndexByte(column, '.'); i != -1 {
+		writeColumn(buf, quote, column[:i])
+		buf.WriteByte('.')
+		column = column[i+1:]
+	}
+	buf.WriteByte(quote)
+	buf.WriteString(column)
+	buf.WriteByte(quote)
+}
+
+// joinClause 以逗号连接同一子句的多个条件
+func joinClause(clause, part string) string {
+	if clause == "" {
+		return part
+	}
+	return clause + "," + part
+}
+
+// toString 将非字符串的字段名参数转换为错误信息中的文本
+func toString(v any) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	return "<non-string>"
+}
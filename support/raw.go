@@ -0,0 +1,206 @@
+// Copyright 2024 OblivionOcean
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package support
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reflect"
+	"unsafe"
+)
+
+// RawQuery 原生 SQL 查询,结果按列名扫描到已注册的模型
+type RawQuery struct {
+	conn   Executor
+	config *Config
+	sql    string
+	args   []any
+	strict bool
+	err    error
+}
+
+// Raw 创建原生 SQL 查询,参数原样交给驱动
+// 与条件字符串一致使用 ? 占位符,PostgreSQL 中展开为 $n(引号内的 ? 原样保留);也可以直接使用数据库原生的占位符
+func (o *ORM) Raw(sqlStr string, args ...any) *RawQuery {
+	if o.config != nil {
+		sqlStr = Rebind(o.config.Dialect, sqlStr)
+	}
+	return &RawQuery{conn: o.conn, config: o.config, sql: sqlStr, args: args}
+}
+
+// RawNamed 创建使用命名参数的原生 SQL 查询
+// query 中的 :name 与 @name 从 arg 取值并展开为所用数据库的占位符,arg 可以是键为字符串的 map 或已注册模型的结构体(指针);
+// 缺少参数或 map 中存在未使用的键时,Scan 返回错误
+func (o *ORM) RawNamed(query string, arg any) *RawQuery {
+	r := &RawQuery{conn: o.conn, config: o.config}
+	if o.config != nil {
+		r.sql, r.args, r.err = o.config.BindNamed(o.config.Dialect, query, arg)
+	}
+	return r
+}
+
+// Strict 返回严格模式的查询副本,结果中存在模型没有的列时 Scan 返回错误
+// 默认忽略这些列
+func (r *RawQuery) Strict() *RawQuery {
+	cp := *r
+	cp.strict = true
+	return &cp
+}
+
+// Scan 执行查询并将结果写入 dest
+// dest 可以是 *T、*[]T 或 *[]*T,T 为已注册的模型;结果列按名称对应模型字段的 db 标签
+// dest 为切片时覆盖原有内容;为结构体时只扫描第一行,没有结果时返回 ErrRecordNotFound
+func (r *RawQuery) Scan(dest any) error {
+	return r.ScanContext(context.Background(), dest)
+}
+
+// ScanContext 与 Scan 相同,使用 ctx 控制查询
+func (r *RawQuery) ScanContext(ctx context.Context, dest any) error {
+	if r.err != nil {
+		return r.err
+	}
+	if r.conn == nil {
+		return errors.New("database is not initialized")
+	}
+	s, err := newRawScanner(dest, r.config)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := r.config.Context(ctx)
+	defer cancel()
+	rows, err := r.conn.QueryContext(ctx, r.sql, r.args...)
+	if err != nil {
+		return TranslateError(err)
+	}
+	defer rows.Close()
+	return s.scan(rows, r.strict)
+}
+
+// rawScanner 按列名将结果扫描到模型
+type rawScanner struct {
+	dest    reflect.Value // 目标结构体或切片
+	many    bool          // 目标为切片
+	ptrRow  bool          // 切片元素为指针
+	rowType reflect.Type  // 模型类型
+	elems   []Elem
+}
+
+func newRawScanner(dest any, config *Config) (*rawScanner, error) {
+	val := reflect.ValueOf(dest)
+	if val.Kind() != reflect.Pointer || val.IsNil() {
+		return nil, errors.New("dest must be a non-nil pointer to a struct or a slice of structs")
+	}
+	s := &rawScanner{dest: val.Elem(), rowType: val.Elem().Type()}
+	if s.rowType.Kind() == reflect.Slice {
+		s.many = true
+		s.rowType = s.rowType.Elem()
+		if s.rowType.Kind() == reflect.Pointer {
+			s.ptrRow = true
+			s.rowType = s.rowType.Elem()
+		}
+	}
+	if s.rowType.Kind() != reflect.Struct {
+		return nil, errors.New("dest must be a non-nil pointer to a struct or a slice of structs")
+	}
+	cache, err := config.Model(reflect.New(s.rowType).Interface())
+	if err != nil {
+		return nil, err
+	}
+	s.elems = cache.Elems
+	return s, nil
+}
+
+// scan 扫描 rows 并写入 dest,字段为 NULL 时保持零值
+// strict 为 false 时丢弃模型中没有对应字段的列
+func (s *rawScanner) scan(rows *sql.Rows, strict bool) error {
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	// index[i] 为第 i 列对应的字段下标,-1 表示丢弃
+	index := make([]int, len(columns))
+	types := make([]reflect.Type, len(columns))
+	for i := 0; i < len(columns); i++ {
+		index[i] = IndexElem(s.elems, columns[i])
+		if index[i] != -1 {
+			types[i] = s.elems[index[i]].Type
+		} else if strict {
+			return errors.New("unknown column: " + columns[i])
+		}
+	}
+	scans := make([]any, len(columns))
+	values := make([]reflect.Value, len(columns))
+	if s.many {
+		s.dest.SetLen(0)
+	}
+
+	for rows.Next() {
+		if err := scanRow(rows, types, scans, values); err != nil {
+			return err
+		}
+		row := reflect.New(s.rowType)
+		base := row.UnsafePointer()
+		for i := 0; i < len(columns); i++ {
+			if !values[i].IsValid() {
+				continue
+			}
+			elem := s.elems[index[i]]
+			reflect.NewAt(elem.Type, unsafe.Add(base, elem.Offset)).Elem().Set(values[i].Elem())
+		}
+
+		if !s.many {
+			s.dest.Set(row.Elem())
+			return TranslateError(rows.Err())
+		}
+		if s.ptrRow {
+			s.dest.Set(reflect.Append(s.dest, row))
+		} else {
+			s.dest.Set(reflect.Append(s.dest, row.Elem()))
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return TranslateError(err)
+	}
+	if !s.many {
+		return ErrRecordNotFound
+	}
+	return nil
+}
+
+// scanRow 扫描当前行,第 i 列扫描为 types[i] 类型的值,types[i] 为 nil 时丢弃该列
+// 扫描后 values[i] 为指向列值的 *T,列为 NULL 或被丢弃时为零值 Value;scans 与 values 的长度与列数相同,可跨行复用
+func scanRow(rows *sql.Rows, types []reflect.Type, scans []any, values []reflect.Value) error {
+	// 扫描到指针的指针,NULL 时保持为 nil
+	for i := 0; i < len(types); i++ {
+		if types[i] == nil {
+			scans[i] = new(any)
+			continue
+		}
+		values[i] = reflect.New(reflect.PointerTo(types[i]))
+		scans[i] = values[i].Interface()
+	}
+	if err := rows.Scan(scans...); err != nil {
+		return err
+	}
+	for i := 0; i < len(types); i++ {
+		if types[i] == nil || values[i].Elem().IsNil() {
+			values[i] = reflect.Value{}
+			continue
+		}
+		values[i] = values[i].Elem()
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+// Copyright 2024 OblivionOcean
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package support
+
+import (
+	"database/sql"
+	"reflect"
+)
+
+// Result 写操作的执行结果
+type Result struct {
+	RowsAffected int64  // 受影响的行数
+	LastInsertId int64  // 最后插入的自增 ID,驱动不支持或没有自增字段时为 0
+	SQL          string // 执行的 SQL 语句,没有需要写入的字段时为空
+}
+
+// NewResult 由 sql.Result 构造 Result,驱动不支持的字段保持为 0
+func NewResult(r sql.Result, sqlStr string) Result {
+	result := Result{SQL: sqlStr}
+	if r == nil {
+		return result
+	}
+	if n, err := r.RowsAffected(); err == nil {
+		result.RowsAffected = n
+	}
+	if id, err := r.LastInsertId(); err == nil {
+		result.LastInsertId = id
+	}
+	return result
+}
+
+// InsertResult 构造通过 RETURNING 插入单行的 Result,LastInsertId 取自回填后的自增字段
+func InsertResult(elems []Elem, sqlStr string) Result {
+	result := Result{RowsAffected: 1, SQL: sqlStr}
+	for i := 0; i < len(elems); i++ {
+		if elems[i].Option["autoIncrement"] != "-" {
+			continue
+		}
+		val := reflect.NewAt(elems[i].Type, elems[i].Ptr).Elem()
+		switch val.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			result.LastInsertId = val.Int()
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			result.LastInsertId = int64(val.Uint())
+		}
+		break
+	}
+	return result
+}
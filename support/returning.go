@@ -0,0 +1,42 @@
+// Copyright 2024 OblivionOcean
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package support
+
+// InsertColumns 计算 Create 的插入字段与需要由数据库回填的字段
+// fields 为 Select/Omit 限定后的字段;自增字段、值为零的 option:"default" 字段以及
+// 未包含在 fields 中的 option:"default" 字段不插入,交由数据库生成,
+// 它们会出现在 generated 中,通过 RETURNING 或重新查询写回对象
+// explicit 为 true 时(Select 显式指定字段)零值的默认值字段同样写入
+func InsertColumns(elems []Elem, fields []Elem, explicit bool) (inserts []Elem, generated []Elem) {
+	inserts = make([]Elem, 0, len(fields))
+	for i := 0; i < len(elems); i++ {
+		if elems[i].Option["autoIncrement"] == "-" {
+			generated = append(generated, elems[i])
+			continue
+		}
+		if IndexElem(fields, elems[i].Tag) == -1 {
+			if elems[i].Option["default"] == "-" {
+				generated = append(generated, elems[i])
+			}
+			continue
+		}
+		if elems[i].Option["default"] == "-" && !explicit && elems[i].Zero() {
+			generated = append(generated, elems[i])
+			continue
+		}
+		inserts = append(inserts, elems[i])
+	}
+	return inserts, generated
+}
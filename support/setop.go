@@ -0,0 +1,61 @@
+// Copyright 2024 OblivionOcean
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package support
+
+import "errors"
+
+// 集合运算符
+const (
+	SetUnion     = "UNION"
+	SetUnionAll  = "UNION ALL"
+	SetIntersect = "INTERSECT"
+	SetExcept    = "EXCEPT"
+)
+
+// SetOperation 集合运算中追加的一个 SELECT,与主查询查询相同的模型、字段与表
+type SetOperation struct {
+	Op    string // 集合运算符
+	Parts []any  // 查询条件部分,只能包含 WHERE 条件
+}
+
+// SetQuery 已解析的集合运算,由 Query.WriteCompound 渲染
+type SetQuery struct {
+	Op    string // 集合运算符
+	Where string // WHERE 条件,不含 WHERE 关键字
+	Args  []any  // WHERE 条件参数
+}
+
+// ResolveSets 使用 build 解析 sets 中各个 SELECT 的条件并保存到 q.Sets
+// 集合运算的结果只能整体排序与分页,q 包含 GROUP BY、HAVING 或游标时返回错误
+func ResolveSets(q *Query, sets []SetOperation, build func(parts []any) (Query, error)) error {
+	if len(sets) == 0 {
+		return nil
+	}
+	if q.GroupBy != "" || q.Having != "" || q.After != "" {
+		return errors.New("GROUP BY, HAVING and cursor pagination are not supported with set operations")
+	}
+	q.Sets = make([]SetQuery, 0, len(sets))
+	for i := 0; i < len(sets); i++ {
+		sq, err := build(sets[i].Parts)
+		if err != nil {
+			return err
+		}
+		if sq.HasClauses() || sq.After != "" {
+			return errors.New("set operation queries only accept WHERE conditions")
+		}
+		q.Sets = append(q.Sets, SetQuery{Op: sets[i].Op, Where: sq.Where, Args: sq.WhereArgs})
+	}
+	return nil
+}
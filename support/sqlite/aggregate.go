@@ -0,0 +1,157 @@
+// Copyright 2024 OblivionOcean
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/OblivionOcean/opao/support"
+)
+
+// Sum 计算 column 的总和并扫描到 dest,没有匹配记录时为 0
+// 参数:
+//   - dest: 结果指针,如 *int64、*float64
+//   - column: 字段名
+//   - queryParts: 查询条件部分,可以是条件字符串和参数
+//
+// 返回:
+//   - error: 执行错误
+func (qt *Sqlite) Sum(dest any, column string, queryParts ...any) error {
+	return qt.AggregateContext(context.Background(), support.SUM, dest, column, queryParts...)
+}
+
+// SumContext 与 Sum 相同,使用 ctx 控制超时与取消
+func (qt *Sqlite) SumContext(ctx context.Context, dest any, column string, queryParts ...any) error {
+	return qt.AggregateContext(ctx, support.SUM, dest, column, queryParts...)
+}
+
+// Avg 计算 column 的平均值并扫描到 dest
+// 没有匹配记录时结果为 NULL,dest 需要使用 *sql.NullFloat64 等可接收 NULL 的类型
+func (qt *Sqlite) Avg(dest any, column string, queryParts ...any) error {
+	return qt.AggregateContext(context.Background(), support.AVG, dest, column, queryParts...)
+}
+
+// AvgContext 与 Avg 相同,使用 ctx 控制超时与取消
+func (qt *Sqlite) AvgContext(ctx context.Context, dest any, column string, queryParts ...any) error {
+	return qt.AggregateContext(ctx, support.AVG, dest, column, queryParts...)
+}
+
+// Min 计算 column 的最小值并扫描到 dest
+// 没有匹配记录时结果为 NULL,dest 需要使用可接收 NULL 的类型
+func (qt *Sqlite) Min(dest any, column string, queryParts ...any) error {
+	return qt.AggregateContext(context.Background(), support.MIN, dest, column, queryParts...)
+}
+
+// MinContext 与 Min 相同,使用 ctx 控制超时与取消
+func (qt *Sqlite) MinContext(ctx context.Context, dest any, column string, queryParts ...any) error {
+	return qt.AggregateContext(ctx, support.MIN, dest, column, queryParts...)
+}
+
+// Max 计算 column 的最大值并扫描到 dest
+// 没有匹配记录时结果为 NULL,dest 需要使用可接收 NULL 的类型
+func (qt *Sqlite) Max(dest any, column string, queryParts ...any) error {
+	return qt.AggregateContext(context.Background(), support.MAX, dest, column, queryParts...)
+}
+
+// MaxContext 与 Max 相同,使用 ctx 控制超时与取消
+func (qt *Sqlite) MaxContext(ctx context.Context, dest any, column string, queryParts ...any) error {
+	return qt.AggregateContext(ctx, support.MAX, dest, column, queryParts...)
+}
+
+// Aggregate 计算 fn(column) 并扫描到 dest,COUNT 的 column 可以为 "*"
+func (qt *Sqlite) Aggregate(fn support.Aggregate, dest any, column string, queryParts ...any) error {
+	return qt.AggregateContext(context.Background(), fn, dest, column, queryParts...)
+}
+
+// AggregateContext 与 Aggregate 相同,使用 ctx 控制超时与取消
+func (qt *Sqlite) AggregateContext(ctx context.Context, fn support.Aggregate, dest any, column string, queryParts ...any) error {
+	ctx, cancel := qt.config.Context(ctx)
+	defer cancel()
+
+	if qt.err != nil {
+		return qt.err
+	}
+	q, err := qt.buildQuery(queryParts...)
+	if err != nil {
+		return err
+	}
+	sqlStr, err := support.AggregateSQL(qt.tables(), qt.clauseElems(), '"', fn, column, "", &q)
+	if err != nil {
+		return err
+	}
+	return support.TranslateError(qt.conn.QueryRowContext(ctx, sqlStr, q.Args()...).Scan(dest))
+}
+
+// CountBy 按 groupColumn 分组统计记录数量,返回分组值到数量的映射
+// 分组值的类型与字段类型相同,[]byte 等切片类型的分组值转换为 string,NULL 分组的键为 nil
+func (qt *Sqlite) CountBy(groupColumn string, queryParts ...any) (map[any]int64, error) {
+	return qt.CountByContext(context.Background(), groupColumn, queryParts...)
+}
+
+// CountByContext 与 CountBy 相同,使用 ctx 控制超时与取消
+func (qt *Sqlite) CountByContext(ctx context.Context, groupColumn string, queryParts ...any) (map[any]int64, error) {
+	groups, err := qt.AggregateByContext(ctx, support.COUNT, "*", groupColumn, queryParts...)
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[any]int64, len(groups))
+	for i := 0; i < len(groups); i++ {
+		counts[support.MapKey(groups[i].Key)] = groups[i].Value.(int64)
+	}
+	return counts, nil
+}
+
+// AggregateBy 按 groupColumn 分组计算 fn(column),按查询结果顺序返回每组的分组值与聚合值
+// queryParts 中可以包含 HAVING、ORDER BY 与 LIMIT,但不能包含 GROUP BY
+// 参数:
+//   - fn: 聚合函数
+//   - column: 聚合字段,COUNT 可以为 "*"
+//   - groupColumn: 分组字段
+//   - queryParts: 查询条件部分,可以是条件字符串和参数
+//
+// 返回:
+//   - []support.Group: 分组结果
+//   - error: 执行错误
+func (qt *Sqlite) AggregateBy(fn support.Aggregate, column, groupColumn string, queryParts ...any) ([]support.Group, error) {
+	return qt.AggregateByContext(context.Background(), fn, column, groupColumn, queryParts...)
+}
+
+// AggregateByContext 与 AggregateBy 相同,使用 ctx 控制超时与取消
+func (qt *Sqlite) AggregateByContext(ctx context.Context, fn support.Aggregate, column, groupColumn string, queryParts ...any) ([]support.Group, error) {
+	ctx, cancel := qt.config.Context(ctx)
+	defer cancel()
+
+	if qt.err != nil {
+		return nil, qt.err
+	}
+	q, err := qt.buildQuery(queryParts...)
+	if err != nil {
+		return nil, err
+	}
+	sqlStr, err := support.AggregateSQL(qt.tables(), qt.clauseElems(), '"', fn, column, groupColumn, &q)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := qt.conn.QueryContext(ctx, sqlStr, q.Args()...)
+	if err != nil {
+		return nil, support.TranslateError(err)
+	}
+	defer func(rows *sql.Rows) {
+		_ = rows.Close()
+	}(rows)
+	return support.ScanGroups(rows, qt.clauseElems(), fn, column, groupColumn)
+}
@@ -0,0 +1,134 @@
+// Copyright 2024 OblivionOcean
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlite
+
+import (
+	"context"
+	"errors"
+
+	"github.com/OblivionOcean/opao/support"
+	"github.com/OblivionOcean/opao/utils"
+)
+
+const (
+	maxParams      = 999   // 3.32.0 之前的绑定参数数量上限
+	maxParamsV3_32 = 32766 // 3.32.0 及之后的绑定参数数量上限
+	versionQuery   = "SELECT sqlite_version()"
+)
+
+// CreateBatch 批量插入记录
+// objs 为已注册结构体或其指针的切片,按 batchSize 拆分为多条 INSERT ... VALUES (...),(...) 语句
+// batchSize <= 0 或超过绑定参数上限(3.32.0 之前为 999,之后为 32766)时按上限拆分
+// 自增字段按 last_insert_rowid 倒推回写(RETURNING 的返回顺序没有保证,不用于回写)
+// 多条语句之间不保证原子性,需要时请在事务中调用
+// 参数:
+//   - objs: 待插入的对象列表
+//   - batchSize: 每条语句插入的最大行数
+//
+// 返回:
+//   - error: 执行错误
+func (qt *Sqlite) CreateBatch(objs any, batchSize int) error {
+	return qt.CreateBatchContext(context.Background(), objs, batchSize)
+}
+
+// CreateBatchContext 与 CreateBatch 相同,使用 ctx 控制超时与取消
+func (qt *Sqlite) CreateBatchContext(ctx context.Context, objs any, batchSize int) error {
+	ctx, cancel := qt.config.Context(ctx)
+	defer cancel()
+
+	if qt.err != nil {
+		return qt.err
+	}
+	rows, err := support.BatchRows(objs, qt.objType)
+	if err != nil || len(rows) == 0 {
+		return err
+	}
+
+	// 区分插入字段与自增字段
+	cols := make([]support.Elem, 0, len(qt.Elems))
+	autoInc := -1
+	for i := 0; i < len(qt.Elems); i++ {
+		if qt.Elems[i].Option["autoIncrement"] == "-" {
+			autoInc = i
+			continue
+		}
+		cols = append(cols, qt.Elems[i])
+	}
+	if len(cols) == 0 {
+		return errors.New("no columns to insert")
+	}
+
+	// 计算每条语句的行数上限
+	version, _ := qt.config.ServerInfo(ctx, qt.conn, versionQuery)
+	maxRows := maxParams / len(cols)
+	if support.VersionAtLeast(version, 3, 32) {
+		maxRows = maxParamsV3_32 / len(cols)
+	}
+	if batchSize <= 0 || batchSize > maxRows {
+		batchSize = maxRows
+	}
+
+	// 构建 INSERT 语句头部
+	header := utils.NewBuffer(22 + len(qt.Table) + len(cols)*8)
+	header.WriteString("INSERT INTO \"")
+	header.WriteString(qt.Table)
+	header.WriteString("\" (")
+	for i := 0; i < len(cols); i++ {
+		header.WriteByte('"')
+		header.WriteString(cols[i].Tag)
+		header.WriteString("\",")
+	}
+	header.TruncateLast(1) // 移除末尾的逗号
+	header.WriteString(") VALUES ")
+
+	values := make([]any, 0, batchSize*len(cols))
+	for start := 0; start < len(rows); {
+		buf := utils.NewBuffer(len(header) + batchSize*(len(cols)*2+2))
+		buf.Write(header...)
+		values = values[:0]
+		end := start
+		for end < len(rows) && end-start < batchSize {
+			buf.WriteByte('(')
+			for i := 0; i < len(cols); i++ {
+				elem := cols[i].At(rows[end])
+				values = append(values, elem.Get())
+				buf.WriteString("?,")
+			}
+			buf.TruncateLast(1) // 移除末尾的逗号
+			buf.WriteString("),")
+			end++
+		}
+		buf.TruncateLast(1) // 移除末尾的逗号
+
+		r, err := qt.conn.ExecContext(ctx, buf.String(), values...)
+		if err != nil {
+			return support.TranslateError(err)
+		}
+
+		// RETURNING 的返回顺序没有保证,统一按 last_insert_rowid 回写自增字段
+		// 单条多行 INSERT 分配连续的 rowid,last_insert_rowid 为本批最后一行的 rowid
+		if autoInc != -1 {
+			if lii, err := r.LastInsertId(); err == nil {
+				first := lii - int64(end-start-1)
+				for i := start; i < end; i++ {
+					elem := qt.Elems[autoInc].At(rows[i])
+					_ = elem.SetInt64(first + int64(i-start))
+				}
+			}
+		}
+		start = end
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+// Copyright 2024 OblivionOcean
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlite
+
+import "github.com/OblivionOcean/opao/support"
+
+// ForUpdate 返回设置排他行锁的 ObjectORM 副本
+// SQLite 没有行锁,写事务会锁定整个数据库,因此不渲染任何子句;
+// 需要在读取前就获得写锁时,应以 BEGIN IMMEDIATE 开启事务(如 mattn/go-sqlite3 的 _txlock=immediate 连接参数)
+// 与其他数据库一致,只能在 Database.Transaction 开启的事务中使用,否则通过 Error 返回 ErrLockOutsideTx
+func (qt *Sqlite) ForUpdate() support.ObjectORM {
+	return qt.withLock(support.LockUpdate, "")
+}
+
+// ForShare 返回设置共享行锁的 ObjectORM 副本,SQLite 中不渲染任何子句
+func (qt *Sqlite) ForShare() support.ObjectORM {
+	return qt.withLock(support.LockShare, "")
+}
+
+// NoWait 返回不等待行锁的 ObjectORM 副本,SQLite 中不渲染任何子句,需要先调用 ForUpdate 或 ForShare
+func (qt *Sqlite) NoWait() support.ObjectORM {
+	return qt.withLock("", support.LockNoWait)
+}
+
+// SkipLocked 返回跳过已锁定行的 ObjectORM 副本,SQLite 中不渲染任何子句,需要先调用 ForUpdate 或 ForShare
+func (qt *Sqlite) SkipLocked() support.ObjectORM {
+	return qt.withLock("", support.LockSkipLocked)
+}
+
+// withLock 返回设置了行锁的副本,错误通过 Error 返回
+func (qt *Sqlite) withLock(mode, wait string) support.ObjectORM {
+	cp := *qt
+	lock, err := qt.lock.With(qt.conn, mode, wait)
+	if err != nil {
+		cp.err = err
+		return &cp
+	}
+	cp.lock = lock
+	return &cp
+}
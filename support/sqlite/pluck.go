@@ -0,0 +1,87 @@
+// Copyright 2024 OblivionOcean
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/OblivionOcean/opao/support"
+)
+
+// Distinct 返回查询时去除重复行的 ObjectORM 副本
+// 指定 columns 时同时限定查询字段(与 Select 相同,但不影响写入);
+// 与 Pluck 组合时只对被提取的字段去重
+func (qt *Sqlite) Distinct(columns ...string) support.ObjectORM {
+	cp := *qt
+	cp.distinct = true
+	if len(columns) == 0 {
+		return &cp
+	}
+	fields, err := support.SelectElems(qt.Elems, columns)
+	if err != nil {
+		cp.err = err
+		return &cp
+	}
+	cp.fields = fields
+	return &cp
+}
+
+// Pluck 查询单个字段,将每行的值依次写入 dest 指向的切片(覆盖原有内容)
+// 参数:
+//   - column: 字段名
+//   - dest: 切片指针,如 *[]int64;元素类型为 any 时按字段类型扫描
+//   - queryParts: 查询条件部分,可以是条件字符串和参数
+//
+// 返回:
+//   - error: 执行错误
+func (qt *Sqlite) Pluck(column string, dest any, queryParts ...any) error {
+	return qt.PluckContext(context.Background(), column, dest, queryParts...)
+}
+
+// PluckContext 与 Pluck 相同,使用 ctx 控制超时与取消
+func (qt *Sqlite) PluckContext(ctx context.Context, column string, dest any, queryParts ...any) error {
+	ctx, cancel := qt.config.Context(ctx)
+	defer cancel()
+
+	if qt.err != nil {
+		return qt.err
+	}
+	index := support.IndexElem(qt.Elems, column)
+	if index == -1 {
+		return errors.New("unknown pluck column: " + column)
+	}
+	slice, err := support.PluckSlice(dest)
+	if err != nil {
+		return err
+	}
+	q, err := qt.buildQuery(queryParts...)
+	if err != nil {
+		return err
+	}
+
+	// 只查询被提取的字段
+	cp := *qt
+	cp.fields = qt.Elems[index : index+1]
+	rows, err := qt.conn.QueryContext(ctx, cp.getSelectSQL(&q), q.Args()...)
+	if err != nil {
+		return support.TranslateError(err)
+	}
+	defer func(rows *sql.Rows) {
+		_ = rows.Close()
+	}(rows)
+	return support.ScanPluck(rows, qt.Elems[index], slice)
+}
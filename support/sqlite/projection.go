@@ -0,0 +1,68 @@
+// Copyright 2024 OblivionOcean
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlite
+
+import "github.com/OblivionOcean/opao/support"
+
+// Select 返回仅读写 columns 字段的 ObjectORM 副本
+// 查询只选择并扫描这些字段;Update、Save、Create 只写入这些字段,零值同样写入
+// 主键条件不受影响,字段名未注册时通过 Error 返回错误
+func (qt *Sqlite) Select(columns ...string) support.ObjectORM {
+	cp := *qt
+	fields, err := support.SelectElems(qt.Elems, columns)
+	if err != nil {
+		cp.err = err
+		return &cp
+	}
+	cp.fields = fields
+	cp.selected = true
+	return &cp
+}
+
+// Omit 返回排除 columns 字段的 ObjectORM 副本
+// 查询不选择这些字段;Update、Save、Create 不写入这些字段
+// 可以与 Select 组合,字段名未注册时通过 Error 返回错误
+func (qt *Sqlite) Omit(columns ...string) support.ObjectORM {
+	cp := *qt
+	fields, err := support.OmitElems(qt.Elems, qt.columns(), columns)
+	if err != nil {
+		cp.err = err
+		return &cp
+	}
+	cp.fields = fields
+	return &cp
+}
+
+// SelectExpr 返回以表达式代替字段查询的 ObjectORM 副本
+// 每个表达式需要通过 As 指定别名,别名必须是已注册的字段名,查询结果扫描到该字段;同一字段再次指定时替换原表达式
+// 作用于 Find、FindAll、Each、Pluck 等按模型扫描的查询,不作用于 Scan;别名无效或渲染失败时通过 Error 返回错误
+func (qt *Sqlite) SelectExpr(exprs ...support.Expr) support.ObjectORM {
+	cp := *qt
+	merged, err := support.SelectExprs(support.DialectSQLite, qt.Elems, qt.exprs, exprs)
+	if err != nil {
+		cp.err = err
+		return &cp
+	}
+	cp.exprs = merged
+	return &cp
+}
+
+// columns 返回 Select/Omit 限定后的字段,未限定时返回全部字段
+func (qt *Sqlite) columns() []support.Elem {
+	if qt.fields != nil {
+		return qt.fields
+	}
+	return qt.Elems
+}
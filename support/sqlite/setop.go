@@ -0,0 +1,50 @@
+// Copyright 2024 OblivionOcean
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlite
+
+import "github.com/OblivionOcean/opao/support"
+
+// Union 返回与 queryParts 匹配的记录合并去重的 ObjectORM 副本
+// queryParts 只能包含 WHERE 条件,追加的 SELECT 与主查询使用相同的字段、表与 JOIN;
+// 查询时传入的条件作用于第一个 SELECT,ORDER BY 与 LIMIT 作用于集合运算的结果,不支持 GROUP BY、HAVING 与游标分页
+// 多个集合运算的优先级由数据库决定,写操作与聚合查询不支持集合运算
+func (qt *Sqlite) Union(queryParts ...any) support.ObjectORM {
+	return qt.setOperation(support.SetUnion, queryParts)
+}
+
+// UnionAll 与 Union 相同,但保留重复的记录
+func (qt *Sqlite) UnionAll(queryParts ...any) support.ObjectORM {
+	return qt.setOperation(support.SetUnionAll, queryParts)
+}
+
+// Intersect 返回与 queryParts 匹配的记录取交集的 ObjectORM 副本,参见 Union
+func (qt *Sqlite) Intersect(queryParts ...any) support.ObjectORM {
+	return qt.setOperation(support.SetIntersect, queryParts)
+}
+
+// Except 返回排除与 queryParts 匹配的记录的 ObjectORM 副本,参见 Union
+func (qt *Sqlite) Except(queryParts ...any) support.ObjectORM {
+	return qt.setOperation(support.SetExcept, queryParts)
+}
+
+// setOperation 返回追加了一个集合运算的副本
+func (qt *Sqlite) setOperation(op string, queryParts []any) support.ObjectORM {
+	cp := *qt
+	if qt.err != nil {
+		return &cp
+	}
+	cp.sets = append(qt.sets[:len(qt.sets):len(qt.sets)], support.SetOperation{Op: op, Parts: queryParts})
+	return &cp
+}
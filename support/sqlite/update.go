@@ -0,0 +1,63 @@
+// Copyright 2024 OblivionOcean
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlite
+
+import (
+	"context"
+	"errors"
+
+	"github.com/OblivionOcean/opao/support"
+)
+
+// UpdateColumns 按 values 更新字段,不读取结构体的字段值
+// values 的键必须是已注册的字段名,值可以是零值或 Expr 表达式;values 为空时不执行任何操作
+// queryParts 为空时按当前对象的主键匹配
+func (qt *Sqlite) UpdateColumns(values map[string]any, queryParts ...any) error {
+	return qt.UpdateColumnsContext(context.Background(), values, queryParts...)
+}
+
+// UpdateColumnsContext 与 UpdateColumns 相同,使用 ctx 控制超时与取消
+func (qt *Sqlite) UpdateColumnsContext(ctx context.Context, values map[string]any, queryParts ...any) error {
+	ctx, cancel := qt.config.Context(ctx)
+	defer cancel()
+
+	sets, err := support.MapAssignments(qt.Elems, values)
+	if err != nil || len(sets) == 0 {
+		return err
+	}
+	query, args, err := qt.writeQuery(queryParts...)
+	if err != nil {
+		return err
+	}
+	sqlStr, args, err := qt.buildUpdate(query, args, sets, false)
+	if err != nil {
+		return err
+	}
+	_, err = qt.conn.ExecContext(ctx, sqlStr, args...)
+	return support.TranslateError(err)
+}
+
+// UpdateFields 按当前对象的主键更新 fields 字段,零值同样写入,其他字段不受影响
+func (qt *Sqlite) UpdateFields(fields ...string) error {
+	return qt.UpdateFieldsContext(context.Background(), fields...)
+}
+
+// UpdateFieldsContext 与 UpdateFields 相同,使用 ctx 控制超时与取消
+func (qt *Sqlite) UpdateFieldsContext(ctx context.Context, fields ...string) error {
+	if len(fields) == 0 {
+		return errors.New("UpdateFields requires at least one field")
+	}
+	return qt.Select(fields...).UpdateContext(ctx)
+}
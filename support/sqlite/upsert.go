@@ -0,0 +1,143 @@
+// Copyright 2024 OblivionOcean
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/OblivionOcean/opao/support"
+	"github.com/OblivionOcean/opao/utils"
+)
+
+// OnConflictDoNothing 返回 Upsert 冲突时不做任何操作的 ObjectORM 副本
+func (qt *Sqlite) OnConflictDoNothing() support.ObjectORM {
+	cp := *qt
+	cp.conflict = support.Conflict{DoNothing: true}
+	return &cp
+}
+
+// OnConflictUpdate 返回 Upsert 冲突时仅更新 columns 字段的 ObjectORM 副本
+func (qt *Sqlite) OnConflictUpdate(columns ...string) support.ObjectORM {
+	cp := *qt
+	cp.conflict = support.Conflict{Update: columns}
+	return &cp
+}
+
+// Upsert 插入记录,conflictColumns 上的唯一约束冲突时更新已有记录
+// 生成 INSERT ... ON CONFLICT ("col") DO UPDATE SET "col"=EXCLUDED."col" 语句
+// 需要 SQLite 3.24.0 及以上版本;未指定冲突字段时使用主键,DO UPDATE 必须有冲突字段
+// 3.35.0 及以上版本通过 RETURNING 回写自增字段,冲突且不做任何操作时不回写;
+// 更早的版本无法区分插入与更新,不回写自增字段
+// 参数:
+//   - conflictColumns: 冲突判断字段
+//
+// 返回:
+//   - error: 执行错误
+func (qt *Sqlite) Upsert(conflictColumns ...string) error {
+	return qt.UpsertContext(context.Background(), conflictColumns...)
+}
+
+// UpsertContext 与 Upsert 相同,使用 ctx 控制超时与取消
+func (qt *Sqlite) UpsertContext(ctx context.Context, conflictColumns ...string) error {
+	ctx, cancel := qt.config.Context(ctx)
+	defer cancel()
+
+	if qt.err != nil {
+		return qt.err
+	}
+	inserts, updates, err := support.UpsertColumns(qt.Elems, conflictColumns, qt.conflict)
+	if err != nil {
+		return err
+	}
+	// 未指定冲突字段时使用主键,值为零的自增主键不插入
+	if len(conflictColumns) == 0 {
+		conflictColumns = support.PrimaryKeyColumns(qt.Elems)
+	}
+	if len(updates) > 0 && len(conflictColumns) == 0 {
+		return errors.New("ON CONFLICT DO UPDATE requires conflict columns")
+	}
+	autoInc := -1
+	for i := 0; i < len(qt.Elems); i++ {
+		if qt.Elems[i].Option["autoIncrement"] == "-" {
+			autoInc = i
+			break
+		}
+	}
+
+	// 构建 INSERT 语句
+	buf := utils.NewBuffer(64 + len(qt.Table) + len(inserts)*16 + len(updates)*24)
+	buf.WriteString("INSERT INTO \"")
+	buf.WriteString(qt.Table)
+	buf.WriteString("\" (")
+	values := make([]any, 0, len(inserts))
+	for i := 0; i < len(inserts); i++ {
+		buf.WriteByte('"')
+		buf.WriteString(inserts[i].Tag)
+		buf.WriteString("\",")
+		values = append(values, inserts[i].Get())
+	}
+	buf.TruncateLast(1) // 移除末尾的逗号
+	buf.WriteString(") VALUES (")
+	for i := 0; i < len(inserts); i++ {
+		buf.WriteString("?,")
+	}
+	buf.TruncateLast(1) // 移除末尾的逗号
+	buf.WriteByte(')')
+
+	// 构建 ON CONFLICT 子句
+	buf.WriteString(" ON CONFLICT")
+	if len(conflictColumns) > 0 {
+		buf.WriteString(" (")
+		for i := 0; i < len(conflictColumns); i++ {
+			buf.WriteByte('"')
+			buf.WriteString(conflictColumns[i])
+			buf.WriteString("\",")
+		}
+		buf.TruncateLast(1) // 移除末尾的逗号
+		buf.WriteByte(')')
+	}
+	if len(updates) == 0 {
+		buf.WriteString(" DO NOTHING")
+	} else {
+		buf.WriteString(" DO UPDATE SET ")
+		for i := 0; i < len(updates); i++ {
+			buf.WriteByte('"')
+			buf.WriteString(updates[i].Tag)
+			buf.WriteString("\"=EXCLUDED.\"")
+			buf.WriteString(updates[i].Tag)
+			buf.WriteString("\",")
+		}
+		buf.TruncateLast(1) // 移除末尾的逗号
+	}
+
+	// 无自增字段或不支持 RETURNING 时直接执行
+	version, _ := qt.config.ServerInfo(ctx, qt.conn, versionQuery)
+	if autoInc == -1 || !support.VersionAtLeast(version, 3, 35) {
+		_, err = qt.conn.ExecContext(ctx, buf.String(), values...)
+		return support.TranslateError(err)
+	}
+
+	// 通过 RETURNING 回写自增字段
+	buf.WriteString(" RETURNING \"")
+	buf.WriteString(qt.Elems[autoInc].Tag)
+	buf.WriteByte('"')
+	err = qt.conn.QueryRowContext(ctx, buf.String(), values...).Scan(qt.Elems[autoInc].GetInterface())
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	return support.TranslateError(err)
+}
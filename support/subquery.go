@@ -0,0 +1,120 @@
+// Copyright 2024 OblivionOcean
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package support
+
+import (
+	"errors"
+
+	"github.com/OblivionOcean/opao/utils"
+)
+
+// Subquery 由已注册模型与查询条件构建的子查询
+type Subquery struct {
+	Model  any    // 已注册的模型,结构体或结构体指针
+	Column string // 查询的字段,为空时查询常量 1,用于 EXISTS
+	Parts  []any  // 查询条件部分,与 FindAll 的参数相同
+}
+
+// SubqueryRenderer 方言渲染子查询的函数,返回使用 ? 占位符的 SQL 与按顺序排列的参数
+type SubqueryRenderer func(sub Subquery) (string, []any, error)
+
+// ResolveSubqueries 将 cond 中 EXISTS、IN 子查询的 Subquery 与 Custom 条件渲染为 SQL 字符串,并展开 Custom 条件的命名参数
+// 渲染后的子查询保存在 EXISTS 的 Left 或 IN 子查询的 Right 中,其参数保存在 Args 中;
+// AND、OR、NOT 组合的条件递归处理,不包含子查询与命名参数的条件原样返回
+func ResolveSubqueries(cond Condition, config *Config, render SubqueryRenderer) (Condition, error) {
+	var err error
+	switch cond.Type {
+	case AND, OR:
+		var args []any
+		for i := 0; i < len(cond.Args); i++ {
+			sub, ok := cond.Args[i].(Condition)
+			if !ok {
+				continue
+			}
+			resolved, err := ResolveSubqueries(sub, config, render)
+			if err != nil {
+				return cond, err
+			}
+			if args == nil {
+				args = append([]any(nil), cond.Args...)
+			}
+			args[i] = resolved
+		}
+		if args != nil {
+			cond.Args = args
+		}
+	case NOT:
+		if sub, ok := cond.Left.(Condition); ok {
+			cond.Left, err = ResolveSubqueries(sub, config, render)
+		}
+	case EXISTS, NOT_EXISTS:
+		cond.Left, cond.Args, err = resolveSubquery(cond.Left, cond.Args, config, render)
+	case IN_SUBQUERY, NOT_IN_SUBQUERY:
+		cond.Right, cond.Args, err = resolveSubquery(cond.Right, cond.Args, config, render)
+	case CUSTOM:
+		if _, ok := cond.Right.(Named); ok {
+			cond.Left, cond.Args, err = resolveCustom(cond, config)
+			cond.Right = nil
+		}
+	}
+	return cond, err
+}
+
+// resolveSubquery 将子查询渲染为 SQL 字符串,字符串原样返回
+func resolveSubquery(v any, args []any, config *Config, render SubqueryRenderer) (string, []any, error) {
+	switch sub := v.(type) {
+	case string:
+		return sub, args, nil
+	case Subquery:
+		return render(sub)
+	case Condition:
+		if sub.Type == CUSTOM {
+			return resolveCustom(sub, config)
+		}
+	}
+	return "", nil, errors.New("subquery must be a SQL string, a Custom condition or a Subquery")
+}
+
+// resolveCustom 返回 Custom 条件的 SQL 与参数,命名参数展开为 ? 占位符
+func resolveCustom(cond Condition, config *Config) (string, []any, error) {
+	query, ok := cond.Left.(string)
+	if !ok {
+		return "", nil, errors.New("custom condition must be a SQL string")
+	}
+	if named, ok := cond.Right.(Named); ok {
+		// 条件中的占位符在生成整条语句时统一编号
+		return config.bindNamed(config.Dialect, query, named.Arg)
+	}
+	return query, cond.Args, nil
+}
+
+// SubquerySQL 生成子查询语句,使用 ? 占位符,quote 为方言的标识符引号
+// column 必须已在 elems 中注册,为空时查询常量 1
+func SubquerySQL(tables []Join, elems []Elem, quote byte, column string, q *Query) (string, error) {
+	buf := utils.NewBuffer(32 + len(tables[0].Table) + len(column) + q.Len())
+	buf.WriteString(q.With)
+	buf.WriteString("SELECT ")
+	if column == "" {
+		buf.WriteByte('1')
+	} else {
+		if IndexElem(elems, column) == -1 {
+			return "", errors.New("unknown subquery column: " + column)
+		}
+		writeColumn(&buf, quote, column)
+	}
+	WriteFrom(&buf, quote, tables)
+	q.WriteTo(&buf)
+	return buf.String(), nil
+}
@@ -0,0 +1,78 @@
+// Copyright 2024 OblivionOcean
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package support
+
+import (
+	"errors"
+
+	"github.com/OblivionOcean/opao/utils"
+)
+
+// Conflict Upsert 的冲突处理方式
+type Conflict struct {
+	DoNothing bool     // 冲突时不做任何操作(INSERT IGNORE / DO NOTHING)
+	Update    []string // 冲突时更新的字段,为空时更新除冲突字段外的所有插入字段
+}
+
+// UpsertColumns 计算 Upsert 的插入字段与冲突时更新的字段
+// 插入字段为所有非自增字段,以及值不为零或在 conflictColumns 中显式列出的自增字段,使按主键 Upsert 时能够命中已有记录;
+// 默认的更新字段不包含自增字段与冲突字段,conflictColumns 为空时按主键排除;
+// conflictColumns 与 Conflict.Update 中的字段名必须已注册,返回的 updates 为空表示冲突时不做任何操作
+func UpsertColumns(elems []Elem, conflictColumns []string, conflict Conflict) (inserts []Elem, updates []Elem, err error) {
+	for i := 0; i < len(conflictColumns); i++ {
+		if IndexElem(elems, conflictColumns[i]) == -1 {
+			return nil, nil, errors.New("unknown conflict column: " + conflictColumns[i])
+		}
+	}
+
+	inserts = make([]Elem, 0, len(elems))
+	for i := 0; i < len(elems); i++ {
+		if elems[i].Option["autoIncrement"] == "-" && elems[i].Zero() && !utils.ContainsInSlice(conflictColumns, elems[i].Tag) {
+			continue
+		}
+		inserts = append(inserts, elems[i])
+	}
+	if len(inserts) == 0 {
+		return nil, nil, errors.New("no columns to insert")
+	}
+	if conflict.DoNothing {
+		return inserts, nil, nil
+	}
+
+	if len(conflict.Update) > 0 {
+		updates = make([]Elem, 0, len(conflict.Update))
+		for i := 0; i < len(conflict.Update); i++ {
+			index := IndexElem(elems, conflict.Update[i])
+			if index == -1 {
+				return nil, nil, errors.New("unknown update column: " + conflict.Update[i])
+			}
+			updates = append(updates, elems[index])
+		}
+		return inserts, updates, nil
+	}
+
+	exclude := conflictColumns
+	if len(exclude) == 0 {
+		exclude = PrimaryKeyColumns(elems)
+	}
+	updates = make([]Elem, 0, len(inserts))
+	for i := 0; i < len(inserts); i++ {
+		if inserts[i].Option["autoIncrement"] == "-" || utils.ContainsInSlice(exclude, inserts[i].Tag) {
+			continue
+		}
+		updates = append(updates, inserts[i])
+	}
+	return inserts, updates, nil
+}
@@ -0,0 +1,101 @@
+// Copyright 2024 OblivionOcean
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package opao
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+
+	"github.com/OblivionOcean/opao/support"
+)
+
+// Tx 数据库事务
+// 提供与 Database 相同的 Register/Load 接口,Load 得到的对象在事务内执行
+type Tx struct {
+	Conn  *sql.Tx
+	depth int // 嵌套层数,0 为最外层事务
+	support.ORM
+}
+
+// Transaction 在事务中执行 fn
+// fn 返回错误或 panic 时回滚,否则提交
+// opts 可指定隔离级别与只读模式,不指定时使用驱动默认值
+// ctx 没有截止时间时 SetTimeout 设置的默认超时作用于整个事务,超时后事务被回滚
+func (db *Database) Transaction(ctx context.Context, fn func(tx *Tx) error, opts ...*sql.TxOptions) (err error) {
+	var opt *sql.TxOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	ctx, cancel := db.Config().Context(ctx)
+	defer cancel()
+
+	conn, err := db.Conn.BeginTx(ctx, opt)
+	if err != nil {
+		return support.TranslateError(err)
+	}
+	tx := &Tx{Conn: conn, ORM: db.ORM.WithConn(conn)}
+
+	defer func() {
+		if p := recover(); p != nil {
+			_ = conn.Rollback()
+			panic(p)
+		}
+	}()
+
+	if err = fn(tx); err != nil {
+		if rbErr := conn.Rollback(); rbErr != nil {
+			return errors.Join(err, rbErr)
+		}
+		return err
+	}
+	return support.TranslateError(conn.Commit())
+}
+
+// Transaction 在当前事务中开启嵌套事务
+// 嵌套事务通过 SAVEPOINT 实现,fn 返回错误或 panic 时回滚到保存点,否则释放保存点
+// 嵌套事务无法修改隔离级别与只读模式
+func (tx *Tx) Transaction(ctx context.Context, fn func(tx *Tx) error, opts ...*sql.TxOptions) (err error) {
+	if len(opts) > 0 && opts[0] != nil && (opts[0].Isolation != sql.LevelDefault || opts[0].ReadOnly) {
+		return errors.New("nested transaction cannot change isolation level or read-only mode")
+	}
+	savepoint := "opao_sp_" + strconv.Itoa(tx.depth+1)
+	if _, err = tx.Conn.ExecContext(ctx, "SAVEPOINT "+savepoint); err != nil {
+		return support.TranslateError(err)
+	}
+	nested := &Tx{Conn: tx.Conn, depth: tx.depth + 1, ORM: tx.ORM}
+
+	defer func() {
+		if p := recover(); p != nil {
+			_, _ = tx.Conn.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+savepoint)
+			panic(p)
+		}
+	}()
+
+	if err = fn(nested); err != nil {
+		if _, rbErr := tx.Conn.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+savepoint); rbErr != nil {
+			return errors.Join(err, rbErr)
+		}
+		return err
+	}
+	_, err = tx.Conn.ExecContext(ctx, "RELEASE SAVEPOINT "+savepoint)
+	return support.TranslateError(err)
+}
+
+// GetConn 返回事务的底层连接
+func (tx *Tx) GetConn() *sql.Tx {
+	return tx.Conn
+}
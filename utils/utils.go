@@ -314,3 +314,21 @@ func CountByte(s []byte, sep byte) int {
 		s = s[i+1:]
 	}
 }
+
+// SnakeCase 将驼峰命名转换为蛇形命名,如 UserID -> user_id
+func SnakeCase(s string) string {
+	buf := make([]byte, 0, len(s)+4)
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c >= 'A' && c <= 'Z' {
+			// 在单词边界处插入下划线: aB -> a_b, ABc -> a_bc
+			if i > 0 && (s[i-1] >= 'a' && s[i-1] <= 'z' || s[i-1] >= '0' && s[i-1] <= '9' ||
+				i+1 < len(s) && s[i+1] >= 'a' && s[i+1] <= 'z' && s[i-1] != '_') {
+				buf = append(buf, '_')
+			}
+			c += 'a' - 'A'
+		}
+		buf = append(buf, c)
+	}
+	return Bytes2String(buf)
+}